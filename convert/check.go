@@ -0,0 +1,91 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Check enforces the rules for gotohugo-friendly source files that
+// convert cannot detect by itself:
+//
+// * Front matter must exist, and it must be valid TOML, YAML, or JSON.
+// * The summary divider must exist exactly once.
+// * A line comment must be followed by code.
+// * There must be no `/* */` comment within a comment/code pair.
+
+// Diagnostic describes a violation of the rules at a source position.
+type Diagnostic struct {
+	Line, Col int // starting at 1
+	Msg       string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s", d.Line, d.Col, d.Msg)
+}
+
+// Check parses src and returns the rule violations, sorted by position.
+func Check(src []byte) []Diagnostic {
+	var diags []Diagnostic
+	report := func(line, col int, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	lines := scanLines(strings.Replace(string(src), "\r", "", -1))
+	d := Parse(src)
+
+	// Front matter must exist, and it must be closed.
+	if len(d.Sections) == 0 {
+		report(1, 1, "missing front matter: add Hugo front matter at the beginning of the first /* */ comment")
+		return diags
+	}
+	fm := d.Sections[0]
+	if len(d.Sections) == 1 {
+		report(fm.Start, 1, "front matter is not closed")
+		return diags
+	}
+	if _, err := decodeFrontMatter(fm); err != nil {
+		e := err.(*Error)
+		report(e.Line, 1, "%v", e.Err)
+	}
+
+	// The summary divider must exist exactly once. Only comments count,
+	// and preformatted text is skipped.
+	first := 0
+	for i, l := range lines {
+		if l.kind == codeLine || !isSummaryDivider(l.raw) || isPreformatted(l.raw) {
+			continue
+		}
+		if first == 0 {
+			first = i + 1
+			continue
+		}
+		report(i+1, strings.Index(l.raw, "<!--more-->")+1, "duplicate summary divider <!--more-->; the first one is at line %d", first)
+	}
+	if first == 0 {
+		report(fm.End, 1, "missing summary divider <!--more--> after the front matter")
+	}
+
+	// A line comment must be followed by code, and a /* */ comment
+	// must not interrupt a comment/code pair.
+	for i, s := range d.Sections {
+		if s.Kind != CommentCodePair || !isBlank(s.Code) {
+			continue
+		}
+		last := s.Start + len(s.Text) - 1
+		if i+1 < len(d.Sections) && d.Sections[i+1].Kind == Doc && d.Sections[i+1].Start == last+1 {
+			next := d.Sections[i+1]
+			report(next.Start, lines[next.Start-1].col, "/* */ comment within a comment/code pair; use // comments only")
+			continue
+		}
+		report(last, lines[last-1].col, "line comment must be followed by code; use a /* */ comment for doc sections")
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Col < diags[j].Col
+	})
+	return diags
+}
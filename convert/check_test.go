@@ -0,0 +1,36 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	const head = "/*\n+++\n+++\nSummary\n<!--more-->\n*/\n" // lines 1-6
+	tests := []struct {
+		name string
+		src  string
+		want []string // "line:col: " prefixes of the diagnostics, in order
+	}{
+		{"valid", head + "\n// Comment.\nvar a = 1\n", nil},
+		{"duplicate divider", head + "\n/*\nDoc\n  <!--more--> again\n*/\n", []string{"10:3: duplicate summary divider"}},
+		{"preformatted divider", head + "\n/*\n    <!--more-->\n*/\n", nil},
+		{"dangling comment", head + "\n\t// Dangling.\n\n/* Doc */\n", []string{"8:2: line comment must be followed by code"}},
+		{"doc within pair", head + "\n// Comment.\n  /* Doc */\nvar a = 1\n", []string{"9:3: /* */ comment within a comment/code pair"}},
+		// The divider is checked first, but the diagnostics come sorted.
+		{"sorted", head + "  // Dangling.\n\n/*\n<!--more-->\n*/\n// Comment.\n/* Doc */\nvar a = 1\n",
+			[]string{"7:3: line comment", "10:1: duplicate summary divider", "13:1: /* */ comment"}},
+	}
+	for _, test := range tests {
+		diags := Check([]byte(test.src))
+		if len(diags) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, diags, test.want)
+			continue
+		}
+		for i, d := range diags {
+			if !strings.HasPrefix(d.String(), test.want[i]) {
+				t.Errorf("%s: got %v, want %s...", test.name, d, test.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Clean removes what ConvertFile wrote for the Go file `filename`, so that
+// a post that has been renamed or deleted leaves no stale pages and media
+// files behind. It removes only files that gotohugo generates or copies:
+// the page and the notebook in the post directory, and in the post's media
+// directory, the copies of the media files that the post refers to, plus
+// the downscaled variants of its images at the widths of SrcsetWidths.
+// Nothing inside the media subfolder next to the Go file is removed, even
+// if that folder lies within the output directories. Directories that end
+// up empty are removed, too. Clean returns the paths of the removed files.
+func (c *Converter) Clean(filename string) (removed []string, err error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file  %s: %w", filename, err)
+	}
+	basename := Base(filepath.Base(filename))
+	outpath := filepath.Join(c.OutDir, c.PostDir, basename)
+	srcMedia := filepath.Join(filepath.Dir(filename), basename)
+	dstMedia := c.mediaPath(basename)
+
+	// remove removes path unless it is a source file or lies outside
+	// root, and then the directories up to root that are empty.
+	remove := func(path, root string) error {
+		if !isWithin(path, root) || isWithin(path, srcMedia) || !isFile(path) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("cannot remove  %s: %w", path, err)
+		}
+		removed = append(removed, path)
+		for dir := filepath.Dir(path); isWithin(dir, root) && !isWithin(dir, srcMedia); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break // not empty
+			}
+		}
+		return nil
+	}
+
+	for _, name := range []string{outputNames[c.Format], basename + ".ipynb"} {
+		if err := remove(filepath.Join(outpath, name), outpath); err != nil {
+			return removed, err
+		}
+	}
+
+	for _, ref := range c.mediaRefs(Parse(src)) {
+		for _, path := range append([]string{ref.Path}, ref.Extra...) {
+			for _, rel := range sourceFiles(filepath.Join(srcMedia, filepath.FromSlash(path))) {
+				if err := remove(filepath.Join(dstMedia, filepath.FromSlash(path), rel), dstMedia); err != nil {
+					return removed, err
+				}
+			}
+		}
+		if ref.Embed != "" {
+			continue
+		}
+		_, format, err := imageConfig(filepath.Join(dstMedia, filepath.FromSlash(ref.Path)))
+		if err != nil {
+			_, format, err = imageConfig(filepath.Join(srcMedia, filepath.FromSlash(ref.Path)))
+		}
+		if err != nil {
+			continue // not an image that gotohugo can scale
+		}
+		for _, w := range c.SrcsetWidths {
+			variant := filepath.Join(dstMedia, filepath.FromSlash(variantName(ref.Path, w, format)))
+			if err := remove(variant, dstMedia); err != nil {
+				return removed, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+// sourceFiles returns the files of the file or directory path, relative
+// to path. A file yields ".", and a missing path yields nothing.
+func sourceFiles(path string) (files []string) {
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(path, p); err == nil {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files
+}
+
+// isWithin returns true if path is dir or lies below dir.
+func isWithin(path, dir string) bool {
+	path, err1 := filepath.Abs(path)
+	dir, err2 := filepath.Abs(dir)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
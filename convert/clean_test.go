@@ -0,0 +1,100 @@
+package convert
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 400, 200))); err != nil {
+		t.Fatal(err)
+	}
+	const post = "/*\n+++\n+++\n<!--more-->\n![Photo](photo.png) ![Logo](logo.svg) ![Shared](../shared.png)\n*/\n"
+
+	tests := []struct {
+		name   string
+		c      func(dir string) *Converter
+		manual string // a file that the author put into the output media dir
+		copied string // a copied image, along with its variant, or "" if nothing is copied
+		shared string // where ../shared.png leads to from the output media dir
+	}{
+		{"separate output", func(dir string) *Converter {
+			return &Converter{OutDir: filepath.Join(dir, "out"), PostDir: "post", MediaDir: "media"}
+		}, "out/media/mypost/manual.png", "out/media/mypost/photo.png", "out/media/shared.png"},
+		// The media dir at the output side is the post directory, which
+		// contains the media folder of the source. Nothing is copied there.
+		{"nested source media", func(dir string) *Converter {
+			return &Converter{OutDir: dir}
+		}, "mypost/manual.png", "", "shared.png"},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		sources := map[string]string{
+			dir + "/mypost/mypost.go":               post,
+			dir + "/mypost/mypost/photo.png":        img.String(),
+			dir + "/mypost/mypost/logo.svg":         "<svg></svg>",
+			dir + "/mypost/mypost/logo-800w.png":    img.String(),
+			dir + "/mypost/mypost/photo-100w.png":   "a source file that looks like a variant",
+			dir + "/mypost/mypost/unused/notes.txt": "notes",
+			dir + "/mypost/shared.png":              img.String(),
+		}
+		writeFiles(t, sources)
+		c := test.c(dir)
+		if err := os.MkdirAll(filepath.Join(c.OutDir, c.PostDir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		c.CopyMedia, c.Notebook, c.ResponsiveImages, c.SrcsetWidths = true, true, true, []int{100}
+		c.Warn = func(error) {}
+		if err := c.ConvertFile(filepath.Join(dir, "mypost", "mypost.go")); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if test.copied == "" {
+			if isFile(filepath.Join(dir, "mypost", "photo.png")) {
+				t.Fatalf("%s: media copied into the source tree", test.name)
+			}
+		} else {
+			for _, path := range []string{test.copied, Base(test.copied) + "-100w.png"} {
+				if !isFile(filepath.Join(dir, filepath.FromSlash(path))) {
+					t.Fatalf("%s: %s not written", test.name, path)
+				}
+			}
+		}
+		// The file that ../shared.png leads to may belong to other posts.
+		writeFiles(t, map[string]string{
+			dir + "/" + test.manual:                     "manual",
+			dir + "/" + test.shared:                     img.String(),
+			dir + "/" + Base(test.shared) + "-100w.png": img.String(),
+		})
+
+		if _, err := c.Clean(filepath.Join(dir, "mypost", "mypost.go")); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		want := []string{
+			filepath.Join(dir, filepath.FromSlash(test.manual)),
+			filepath.Join(dir, filepath.FromSlash(test.shared)),
+			filepath.Join(dir, filepath.FromSlash(Base(test.shared)+"-100w.png")),
+		}
+		for path := range sources {
+			want = append(want, filepath.FromSlash(path))
+		}
+		var got []string
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				got = append(got, path)
+			}
+			return nil
+		})
+		sort.Strings(want)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: files after clean:\n%v\nwant\n%v", test.name, got, want)
+		}
+	}
+}
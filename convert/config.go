@@ -0,0 +1,175 @@
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// The settings of a project can be stored in a file `gotohugo.toml`. The file
+// applies to the directory that contains it and to all directories below. A
+// post directory can contain a `gotohugo.toml` of its own that overrides
+// some of the project settings for this post only. The keys are the names of
+// the command line flags:
+//
+//	hugo = "../blog"      # paths are relative to the directory of the file
+//	section = "tutorials"
+//	layout = "layout"
+//	announcement = "newsletter"
+//	codeclass = "language-go"
+//	srcset = [320, 640]
+//	embeds = ["HYPE", "SVG"]
+
+// ConfigFile is the name of the configuration file.
+const ConfigFile = "gotohugo.toml"
+
+// Config contains the settings from the configuration files.
+type Config struct {
+	Out            string   `toml:"out"`            // the output directory
+	Hugo           string   `toml:"hugo"`           // the Hugo root directory; takes precedence over Out
+	Section        string   `toml:"section"`        // the content section for posts in a Hugo site
+	Layout         string   `toml:"layout"`         // a directory with layout templates
+	Format         string   `toml:"format"`         // the output format
+	Announcement   string   `toml:"announcement"`   // see Converter.Announcement
+	CodeClass      string   `toml:"codeclass"`      // see Converter.CodeClass
+	KeepImagePaths bool     `toml:"keepimagepaths"` // see Converter.KeepImagePaths
+	Notebook       bool     `toml:"notebook"`       // see Converter.Notebook
+	CopyMedia      bool     `toml:"copymedia"`      // see Converter.CopyMedia
+	VerifyMedia    bool     `toml:"verifymedia"`    // see Converter.VerifyMedia
+	UnusedMedia    bool     `toml:"unusedmedia"`    // see Converter.ReportUnusedMedia
+	Responsive     bool     `toml:"responsive"`     // see Converter.ResponsiveImages
+	Srcset         []int    `toml:"srcset"`         // see Converter.SrcsetWidths
+	Embeds         []string `toml:"embeds"`         // the enabled embed tags; all built-in ones if empty
+	Enrich         bool     `toml:"enrich"`         // see Converter.EnrichFrontMatter
+	TagImports     bool     `toml:"tagimports"`     // see Converter.TagImports
+	Strict         bool     `toml:"strict"`         // see Converter.Strict
+
+	// ImportTags adds to or overrides the mapping of DefaultImportTags.
+	// An empty tag removes a mapping.
+	ImportTags map[string]string `toml:"importtags"`
+
+	Files []string `toml:"-"` // the configuration files read, outermost first
+}
+
+// DefaultConfig returns the settings that apply if
+// there is no configuration file.
+func DefaultConfig() *Config {
+	return &Config{Format: Hugo.String(), CopyMedia: true, VerifyMedia: true}
+}
+
+// LoadConfig reads the configuration files that apply to dir, on top of
+// the defaults. It looks for `gotohugo.toml` files in dir and in all of
+// its parent directories. The settings of a file override the settings
+// of the files in the directories above.
+func LoadConfig(dir string) (*Config, error) {
+	cfg := DefaultConfig()
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find configuration files for  %s: %w", dir, err)
+	}
+	var files []string
+	for {
+		file := filepath.Join(dir, ConfigFile)
+		if isFile(file) {
+			files = append([]string{file}, files...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	for _, file := range files {
+		if err := cfg.read(file); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// read takes the settings from file. Relative paths in the file
+// are relative to the directory of the file.
+func (cfg *Config) read(file string) error {
+	md, err := toml.DecodeFile(file, cfg)
+	if err != nil {
+		return fmt.Errorf("cannot read configuration file  %s: %w", file, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown setting %q in configuration file  %s", undecoded[0].String(), file)
+	}
+	for key, path := range map[string]*string{"out": &cfg.Out, "hugo": &cfg.Hugo, "layout": &cfg.Layout} {
+		if md.IsDefined(key) && *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(filepath.Dir(file), *path)
+		}
+	}
+	cfg.Files = append(cfg.Files, file)
+	return nil
+}
+
+// Converter returns a Converter with the settings of cfg. With Hugo set,
+// it reads the config of the Hugo site for the output directories.
+func (cfg *Config) Converter() (*Converter, error) {
+	f, err := ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	c := &Converter{
+		OutDir:            cfg.Out,
+		Format:            f,
+		Announcement:      cfg.Announcement,
+		CodeClass:         cfg.CodeClass,
+		KeepImagePaths:    cfg.KeepImagePaths,
+		Notebook:          cfg.Notebook,
+		CopyMedia:         cfg.CopyMedia,
+		VerifyMedia:       cfg.VerifyMedia,
+		ReportUnusedMedia: cfg.UnusedMedia,
+		ResponsiveImages:  cfg.Responsive,
+		SrcsetWidths:      cfg.Srcset,
+		EnrichFrontMatter: cfg.Enrich,
+		TagImports:        cfg.TagImports,
+		Strict:            cfg.Strict,
+	}
+
+	if cfg.Hugo != "" {
+		site, err := ReadHugoSite(cfg.Hugo)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Section != "" {
+			site.Section = cfg.Section
+		}
+		site.Configure(c)
+	}
+
+	if cfg.Layout != "" {
+		files, err := filepath.Glob(filepath.Join(cfg.Layout, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("cannot find layout files: %w", err)
+		}
+		c.Layout, err = LoadLayout(f, files...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.ImportTags) > 0 {
+		c.ImportTags = DefaultImportTags()
+		for path, tag := range cfg.ImportTags {
+			c.ImportTags[path] = tag
+		}
+	}
+
+	if len(cfg.Embeds) > 0 {
+		all := DefaultEmbeds()
+		c.Embeds = map[string]Embedder{}
+		for _, name := range cfg.Embeds {
+			e, ok := all[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown embed tag %q", name)
+			}
+			c.Embeds[name] = e
+		}
+	}
+	return c, nil
+}
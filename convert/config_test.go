@@ -0,0 +1,35 @@
+package convert
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	post := filepath.Join(root, "part1")
+	writeFiles(t, map[string]string{
+		filepath.Join(root, ConfigFile): "hugo = \"blog\"\nsection = \"tutorials\"\nsrcset = [320, 640]\n",
+		filepath.Join(post, ConfigFile): "section = \"go\"\nstrict = true\n",
+	})
+
+	cfg, err := LoadConfig(post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	want.Hugo = filepath.Join(root, "blog")
+	want.Section = "go"
+	want.Srcset = []int{320, 640}
+	want.Strict = true
+	want.Files = []string{filepath.Join(root, ConfigFile), filepath.Join(post, ConfigFile)}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v, want %+v", *cfg, *want)
+	}
+
+	writeFiles(t, map[string]string{filepath.Join(post, ConfigFile): "sektion = \"go\"\n"})
+	if _, err := LoadConfig(post); err == nil {
+		t.Error("unknown setting: want error, got nil")
+	}
+}
@@ -0,0 +1,259 @@
+// Package convert turns commented Go source files into Markdown documents
+// with custom Hugo shortcodes.
+//
+// Comments become Markdown text, code becomes fenced code blocks, and
+// shortcodes around doc and code parts allow a side-by-side layout of
+// comments and code.
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Format is an output format of the Converter.
+type Format int
+
+const (
+	Hugo       Format = iota // Markdown with Hugo shortcodes
+	CommonMark               // plain Markdown without shortcodes
+	HTML                     // a standalone HTML page
+)
+
+var formatNames = [...]string{"hugo", "commonmark", "html"}
+
+// outputNames are the file names that ConvertFile writes for each format.
+var outputNames = [...]string{"index.md", "index.md", "index.html"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return "Format(" + strconv.Itoa(int(f)) + ")"
+	}
+	return formatNames[f]
+}
+
+// ParseFormat returns the Format of the given name.
+func ParseFormat(name string) (Format, error) {
+	for i, n := range formatNames {
+		if strings.EqualFold(name, n) {
+			return Format(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown output format %q", name)
+}
+
+// Defaults of the Converter settings.
+const (
+	DefaultAnnouncement = "announcement"
+	DefaultCodeClass    = "language-klipse-go" // used by the Klipse plugin
+)
+
+// Converter converts commented Go source into Hugo Markdown.
+// The zero value writes page bundles to the current directory.
+type Converter struct {
+	// OutDir is the output directory. Page bundles are created
+	// at OutDir/PostDir/<name>/index.md.
+	OutDir string
+	// PostDir is the post directory relative to OutDir,
+	// for example "content/post" in a Hugo site.
+	PostDir string
+	// MediaDir is the directory relative to OutDir
+	// that contains a subdirectory of media files for each post.
+	MediaDir string
+	// PublicMediaDir is the media dir as the Web server sees it.
+	PublicMediaDir string
+	// Name is the base name of the post, used for extending media paths.
+	// ConvertFile sets it from the file name.
+	Name string
+	// Format is the output format.
+	Format Format
+	// Layout contains the templates for the markup around each section.
+	// If nil, the layout of the output format is used.
+	Layout *Layout
+	// Announcement is the name of the shortcode that the default layout
+	// inserts after the summary. If empty, DefaultAnnouncement is used.
+	Announcement string
+	// CodeClass is the class of the code div in the default layout.
+	// If empty, DefaultCodeClass is used.
+	CodeClass string
+	// KeepImagePaths disables extending the paths of image tags.
+	KeepImagePaths bool
+	// Embeds are the embed types by tag name, like "HYPE".
+	// If nil, the built-in embed types of DefaultEmbeds are used.
+	Embeds map[string]Embedder
+	// ResponsiveImages turns image tags into `<img>` elements with the width
+	// and height of the image, read from `OutDir/MediaDir/<name>/`.
+	ResponsiveImages bool
+	// SrcsetWidths are the widths of downscaled copies of each image that
+	// ResponsiveImages writes next to the image and adds to the `srcset`
+	// attribute. Widths that are not smaller than the image are skipped.
+	SrcsetWidths []int
+	// Notebook makes ConvertFile write a Jupyter notebook `<basename>.ipynb`
+	// into the page bundle, in addition to the regular output.
+	Notebook bool
+	// CopyMedia makes ConvertFile copy the images and Hype animations that
+	// the post refers to from the folder `<name>/` next to the source file
+	// to `OutDir/MediaDir/<name>/`. Unchanged files are skipped.
+	CopyMedia bool
+	// VerifyMedia makes ConvertFile check that each image and Hype animation
+	// that the post refers to exists in the folder `<name>/` next to the
+	// source file or in `OutDir/MediaDir/<name>/`. Missing files are
+	// reported like other problems, but not embedded into the page.
+	VerifyMedia bool
+	// ReportUnusedMedia makes VerifyMedia also warn about files
+	// in the folder `<name>/` that the post does not refer to.
+	ReportUnusedMedia bool
+	// EnrichFrontMatter makes ConvertFile set lastmod, wordCount,
+	// readingTime, and codeLines in the front matter (see Enrich).
+	EnrichFrontMatter bool
+	// TagImports makes ConvertFile add tags for the packages that the
+	// source imports to the front matter (see TagsFromImports and AddTags).
+	TagImports bool
+	// ImportTags maps import paths to tags for TagImports.
+	// If nil, the mapping of DefaultImportTags is used.
+	ImportTags map[string]string
+	// Strict aborts the conversion at the first problem in the source,
+	// like a missing Hype file. Otherwise, a warning is embedded into the page.
+	Strict bool
+	// Warn receives the problems that are embedded into the page if Strict is
+	// not set. If nil, they are written to the standard logger.
+	Warn func(err error)
+
+	ids    map[string]int // the ids that embed tags have used, per document
+	srcDir string         // the directory of the source file, if known
+}
+
+// Convert reads commented Go source from r and writes
+// the Markdown document (or HTML page) to w.
+func (c *Converter) Convert(r io.Reader, w io.Writer) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("cannot read source: %w", err)
+	}
+	return c.Render(w, Parse(src))
+}
+
+// Base strips the extension from a filename. For some reason, this
+// function is missing from the standard path library.
+func Base(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// ConvertFile takes a file name, reads that file, converts it to
+// Markdown, and writes it to `OutDir/PostDir/<basename>/index.md`.
+// For the HTML format, the file name is `index.html`.
+// If Notebook is set, it also writes `<basename>.ipynb` to the same directory.
+// If CopyMedia is set, it copies the media files before converting.
+// If TagImports is set, it adds tags for the imported packages, and if
+// EnrichFrontMatter is set, it adds statistics and the time of the last
+// change of the file to the front matter.
+// If VerifyMedia is set, it reports missing media files.
+// It creates the page bundle directory but expects the base path to exist.
+func (c *Converter) ConvertFile(filename string) (err error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot read file  %s: %w", filename, err)
+	}
+
+	basename := Base(filepath.Base(filename)) // strip ".go"
+	outpath := filepath.Join(c.OutDir, c.PostDir, basename)
+	srcMedia := filepath.Join(filepath.Dir(filename), basename)
+	// Without an output directory, converting `<name>.go` from within its
+	// own directory would write the page into the media folder `<name>/`.
+	if isWithin(outpath, srcMedia) {
+		return fmt.Errorf("cannot convert %s: the output directory  %s is the media folder of the post. Set an output directory, like the root of a Hugo site", filename, outpath)
+	}
+	// Create the output directory if it doesn't exist.
+	if _, err := os.Stat(outpath); err != nil {
+		if os.IsNotExist(err) {
+			if err = os.Mkdir(outpath, fs.ModeDir|0774); err != nil {
+				return fmt.Errorf("Cannot create output directory  %s: %w", outpath, err)
+			}
+		} else {
+			return fmt.Errorf("Cannot stat output directory  %s: %w", outpath, err)
+		}
+	}
+	fc := *c
+	fc.Name = basename
+	fc.srcDir = filepath.Dir(filename)
+	doc := Parse(src)
+
+	// Without valid front matter, there is nothing to enrich. A problem
+	// aborts the conversion in strict mode. Otherwise, the conversion goes
+	// on without the enrichment.
+	if c.TagImports {
+		mapping := c.ImportTags
+		if mapping == nil {
+			mapping = DefaultImportTags()
+		}
+		tags, err := TagsFromImports(src, mapping)
+		if err == nil {
+			err = AddTags(doc, tags)
+		}
+		if err != nil {
+			if c.Strict {
+				return fmt.Errorf("cannot convert %s: %w", filename, err)
+			}
+			c.warn(fmt.Errorf("cannot add import tags to %s: %w", filename, err))
+		}
+	}
+	if c.EnrichFrontMatter {
+		lastmod, err := LastModified(filename)
+		if err != nil {
+			return err
+		}
+		if err = Enrich(doc, lastmod); err != nil {
+			if c.Strict {
+				return fmt.Errorf("cannot convert %s: %w", filename, err)
+			}
+			c.warn(fmt.Errorf("cannot enrich the front matter of %s: %w", filename, err))
+		}
+	}
+
+	// Hype snippets are read from the destination, so copy the media first.
+	// Without an output directory, the destination lies next to the source
+	// file, and copying would litter the source tree.
+	if c.CopyMedia && !isWithin(c.mediaPath(basename), filepath.Dir(filename)) {
+		err = fc.copyMedia(doc, srcMedia, c.mediaPath(basename))
+		if err != nil {
+			return fmt.Errorf("cannot copy media files of %s: %w", filename, err)
+		}
+	}
+	if c.VerifyMedia {
+		err = c.verifyMedia(doc, srcMedia, c.mediaPath(basename), c.ReportUnusedMedia)
+		if err != nil {
+			return fmt.Errorf("cannot convert %s: %w", filename, err)
+		}
+	}
+
+	// Render everything before writing anything, so that an error in
+	// strict mode leaves the existing files untouched.
+	var out, nb bytes.Buffer
+	if err = fc.Render(&out, doc); err != nil {
+		return fmt.Errorf("cannot convert %s: %w", filename, err)
+	}
+	if c.Notebook {
+		if err = fc.WriteNotebook(&nb, doc); err != nil {
+			return fmt.Errorf("cannot convert %s: %w", filename, err)
+		}
+	}
+	if err = writeFile(filepath.Join(outpath, outputNames[c.Format]), out.Bytes()); err != nil || !c.Notebook {
+		return err
+	}
+	return writeFile(filepath.Join(outpath, basename+".ipynb"), nb.Bytes())
+}
+
+// writeFile creates or truncates the file `name` and writes `content` to it.
+func writeFile(name string, content []byte) error {
+	err := os.WriteFile(name, content, 0644) // -rw-r--r--
+	if err != nil {
+		return fmt.Errorf("cannot write file  %s: %w", name, err)
+	}
+	return nil
+}
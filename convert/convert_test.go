@@ -0,0 +1,120 @@
+package convert
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata.")
+
+// newTestConverter returns a Converter for the post `name` in testdata
+// that does not log warnings.
+func newTestConverter(name string) *Converter {
+	return &Converter{OutDir: "testdata", Name: name, Warn: func(error) {}}
+}
+
+// writeFiles creates the files with the given contents, along with
+// their directories. The paths may use forward slashes.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.FromSlash(name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestGolden converts each testdata/<name>.go file and compares the
+// result with testdata/<name>.md. Run `go test -update` to regenerate
+// the golden files after an intended change of the output.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		name := Base(filepath.Base(file))
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := newTestConverter(name).Convert(bytes.NewReader(src), &out); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name+".md")
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != string(want) {
+				t.Errorf("output differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// checkBalanced verifies that every div shortcode is closed
+// and that code fences come in pairs.
+func checkBalanced(t *testing.T, md string) {
+	depth, fences := 0, 0
+	for i, line := range strings.Split(md, "\n") {
+		switch {
+		case strings.HasPrefix(line, "{{< divend >}}"):
+			depth--
+			if depth < 0 {
+				t.Fatalf("line %d: divend without div:\n%s", i+1, md)
+			}
+		case strings.HasPrefix(line, "{{< div "):
+			depth++
+		case strings.HasPrefix(line, "```"):
+			fences++
+		}
+	}
+	if depth != 0 {
+		t.Errorf("%d divs not closed:\n%s", depth, md)
+	}
+	if fences%2 != 0 {
+		t.Errorf("code fence not closed:\n%s", md)
+	}
+}
+
+// FuzzConvert asserts that the generated Markdown is well-formed
+// for arbitrary input.
+func FuzzConvert(f *testing.F) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "*.go"))
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(src)
+	}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		// Shortcodes and fences in the input are passed through
+		// and would break the invariants.
+		if bytes.Contains(src, []byte("{{<")) || bytes.Contains(src, []byte("```")) {
+			t.Skip()
+		}
+		var out bytes.Buffer
+		if err := newTestConverter("fuzz").Convert(bytes.NewReader(src), &out); err != nil {
+			t.Fatal(err)
+		}
+		checkBalanced(t, out.String())
+	})
+}
@@ -0,0 +1,143 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Media files of a post live in the subfolder `<name>/` next to the source
+// file `<name>.go`. ConvertFile can copy the files that the post refers to
+// from there to the media directory of the post, so that they do not have
+// to be placed there by hand. Files that have not changed are not copied again.
+
+const imageRefPtrn = `!\[[^\]]*\]\(\s*([^)"]*?)\s*(?:"[^"]*")?\s*\)`
+
+var imageRef = regexp.MustCompile(imageRefPtrn) // captures the path of a Markdown image tag
+
+// mediaRef is a media file that a post refers to.
+type mediaRef struct {
+	Path  string   // relative to the media folder
+	Line  int      // the source line of the reference
+	Embed string   // the name of the embed tag, or "" for an image tag
+	Extra []string // more files that belong to an embed tag, see MediaLister
+}
+
+// isLocal returns true if path refers to a file in the media folder
+// rather than to a URL or an absolute path.
+func isLocal(path string) bool {
+	return path != "" && !strings.Contains(path, "://") && !strings.HasPrefix(path, "/")
+}
+
+// mediaRefs returns the image and embed tag references in the prose of d.
+// Preformatted text is skipped.
+func (c *Converter) mediaRefs(d *Document) (refs []mediaRef) {
+	embeds := c.embeds()
+	for _, s := range d.Sections {
+		if s.Kind == FrontMatter || s.Kind == Plain {
+			continue
+		}
+		for i, line := range s.Text {
+			if isPreformatted(line) {
+				continue
+			}
+			for _, m := range imageRef.FindAllStringSubmatch(line, -1) {
+				if isLocal(m[1]) {
+					refs = append(refs, mediaRef{Path: m[1], Line: s.Start + i})
+				}
+			}
+			if m := findEmbedTag(line, embeds); m != nil && isLocal(m[3]) {
+				ref := mediaRef{Path: m[3], Line: s.Start + i, Embed: m[1]}
+				if l, ok := embeds[m[1]].(MediaLister); ok {
+					ref.Extra = l.MediaFiles(m[3])
+				}
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// mediaPath returns the directory where the media files of the post
+// `basename` are expected at the output side.
+func (c *Converter) mediaPath(basename string) string {
+	return filepath.Join(c.OutDir, c.MediaDir, basename)
+}
+
+// copyMedia copies the files that d refers to from srcDir to dstDir.
+// For a Hype animation, the `<name>.hyperesources` directory is copied
+// along with the HTML file. References to files that do not exist in
+// srcDir are skipped. References that lead out of dstDir, like
+// `../shared/logo.png`, are reported and skipped.
+func (c *Converter) copyMedia(d *Document, srcDir, dstDir string) error {
+	for _, ref := range c.mediaRefs(d) {
+		for _, path := range append([]string{ref.Path}, ref.Extra...) {
+			src := filepath.Join(srcDir, path)
+			dst := filepath.Join(dstDir, path)
+			if !isWithin(dst, dstDir) {
+				if _, err := c.problem(ref.Line, fmt.Errorf("cannot copy  %s: the file is outside the media folder of the post", path)); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := os.Stat(src); os.IsNotExist(err) {
+				continue
+			}
+			if err := copyTree(src, dst); err != nil {
+				return &Error{Line: ref.Line, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// copyTree copies a file, or a directory and all of its content.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target, info)
+	})
+}
+
+// copyFile copies the file src to dst unless dst has the same size and
+// modification time. The copy gets the modification time of src.
+func copyFile(src, dst string, info os.FileInfo) (err error) {
+	if dstInfo, err := os.Stat(dst); err == nil {
+		if dstInfo.Size() == info.Size() && dstInfo.ModTime().Equal(info.ModTime()) {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("cannot create directory  %s: %w", filepath.Dir(dst), err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("cannot copy  %s: %w", src, err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644) // -rw-r--r--
+	if err != nil {
+		return fmt.Errorf("cannot copy to  %s: %w", dst, err)
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot copy  %s to  %s: %w", src, dst, err)
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,96 @@
+package convert
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyMedia(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	writeFiles(t, map[string]string{
+		src + "/post.go": "/*\n+++\n+++\n<!--more-->\n![Image](image.png)\n\n" +
+			"HYPE[Animation](anim.html)\n\n![Missing](missing.png) ![Remote](https://example.com/x.png)\n*/\n",
+		src + "/post/image.png":                    "png",
+		src + "/post/anim.html":                    "<!-- copy these lines to your document: -->\n<div></div>\n<!-- end copy -->\n",
+		src + "/post/anim.hyperesources/script.js": "js",
+		src + "/post/unused.png":                   "unused",
+	})
+
+	c := &Converter{OutDir: out, CopyMedia: true, Warn: func(error) {}}
+	if err := c.ConvertFile(filepath.Join(src, "post.go")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"image.png", "anim.html", "anim.hyperesources/script.js"} {
+		if _, err := os.Stat(filepath.Join(out, "post", filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s not copied: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "post", "unused.png")); err == nil {
+		t.Error("unused.png copied")
+	}
+
+	// An unchanged file is not copied again.
+	copied := filepath.Join(out, "post", "image.png")
+	if err := os.WriteFile(copied, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(filepath.Join(src, "post", "image.png"))
+	if err := os.Chtimes(copied, time.Now(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ConvertFile(filepath.Join(src, "post.go")); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(copied); string(content) != "new" {
+		t.Errorf("unchanged image.png copied again")
+	}
+}
+
+// TestCopyMediaSourceTree asserts that without an output directory,
+// ConvertFile does not copy the media files next to the source file.
+func TestCopyMediaSourceTree(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		dir + "/mypost/mypost.go":      "/*\n+++\n+++\n<!--more-->\n![Image](pic.png)\n*/\n",
+		dir + "/mypost/mypost/pic.png": "png",
+	})
+	c := &Converter{OutDir: dir, CopyMedia: true, Warn: func(error) {}}
+	if err := c.ConvertFile(filepath.Join(dir, "mypost", "mypost.go")); err != nil {
+		t.Fatal(err)
+	}
+	if isFile(filepath.Join(dir, "mypost", "pic.png")) {
+		t.Error("pic.png copied next to the source file")
+	}
+}
+
+// TestCopyMediaOutside asserts that ConvertFile does not copy files that
+// a reference leads to outside the media folder, and reports the line.
+func TestCopyMediaOutside(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, map[string]string{
+		root + "/src/post.go":       "/*\n+++\n+++\n<!--more-->\n![Logo](../../shared/logo.png)\n*/\n",
+		root + "/shared/logo.png":   "png",
+		root + "/a/out/placeholder": "",
+	})
+	for _, strict := range []bool{false, true} {
+		var warnings []error
+		c := &Converter{OutDir: root + "/a/out", CopyMedia: true, Strict: strict, Warn: func(err error) { warnings = append(warnings, err) }}
+		err := c.ConvertFile(filepath.Join(root, "src", "post.go"))
+		if strict {
+			warnings = append(warnings, err)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		var e *Error
+		if len(warnings) == 0 || !errors.As(warnings[0], &e) || e.Line != 5 {
+			t.Errorf("strict %t: got %v, want an error at line 5", strict, warnings)
+		}
+		if isFile(filepath.Join(root, "a", "shared", "logo.png")) {
+			t.Fatalf("strict %t: logo.png copied outside the media folder", strict)
+		}
+	}
+}
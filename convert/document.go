@@ -0,0 +1,201 @@
+package convert
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const frontmatterPtrn = `^\s*(\+\+\+|---)\s*$`
+
+var frontmatterDelim = regexp.MustCompile(frontmatterPtrn) // matches Hugo front matter delimiters
+
+// SectionKind identifies the type of a Section.
+type SectionKind int
+
+const (
+	FrontMatter     SectionKind = iota // Hugo front matter, including the delimiters
+	Summary                            // the summary before the `<!--more-->` divider
+	Intro                              // the rest of the first multiline comment
+	Doc                                // a pure doc section, enclosed in /* */
+	CommentCodePair                    // a // comment and the code that follows it
+	Plain                              // lines outside any section, passed through verbatim
+)
+
+var sectionKindNames = [...]string{"FrontMatter", "Summary", "Intro", "Doc", "CommentCodePair", "Plain"}
+
+func (k SectionKind) String() string {
+	if k < 0 || int(k) >= len(sectionKindNames) {
+		return "SectionKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return sectionKindNames[k]
+}
+
+// Section is a part of a Document.
+//
+// Text contains the Markdown lines of the section with comment delimiters
+// stripped. For a CommentCodePair, Text is the comment and Code contains the code lines.
+// Start and End are the first and last source line of the section, starting at 1.
+type Section struct {
+	Kind       SectionKind
+	Start, End int
+	Text       []string
+	Code       []string
+}
+
+// Document is the parsed form of a commented Go file.
+// Anything before the front matter is not part of the Document.
+type Document struct {
+	Sections []*Section
+}
+
+// isFrontmatterDelim returns true if it finds a frontmatter
+// delimiter in the current line.
+func isFrontmatterDelim(line string) bool {
+	return frontmatterDelim.FindString(line) != ""
+}
+
+// isSummaryDivider detects the summary divider.
+func isSummaryDivider(line string) bool {
+	return strings.Contains(line, "<!--more-->")
+}
+
+// Parse receives commented Go code and splits it line by line into the
+// sections of a Document.
+func Parse(src []byte) *Document {
+	const (
+		beforefrontmatter = iota
+		frontmatter
+		summary
+		intro
+		doc
+		comment
+		code
+		none
+	)
+	status := beforefrontmatter
+	d := &Document{}
+	var sec *Section
+	jsonFM, depth := false, 0 // JSON front matter ends with the closing brace of the object
+
+	// open starts a new section at line n.
+	open := func(kind SectionKind, n int) {
+		sec = &Section{Kind: kind, Start: n, End: n}
+		d.Sections = append(d.Sections, sec)
+	}
+	// add appends a line of text to the current section.
+	add := func(text string, n int) {
+		sec.Text = append(sec.Text, text)
+		sec.End = n
+	}
+
+	// Turn CR/LF line endings into pure LF line endings.
+	in := strings.Replace(string(src), "\r", "", -1)
+	// Split into classified lines and process each line.
+	for i, sl := range scanLines(in) {
+		n := i + 1
+		line := sl.raw
+
+		// If the line belongs to Hugo front matter, add it to the front matter
+		// section and continue with the next line.
+		if status == beforefrontmatter {
+			if isFrontmatterDelim(line) || isJSONStart(line) { // start of front matter.
+				status = frontmatter
+				open(FrontMatter, n)
+				add(line, n)
+				jsonFM, depth = isJSONStart(line), 1
+			}
+			// Discard anything before the front matter. There should **only**
+			// be an optional //go:... directive, and the start of the first
+			// multiline comment, and nothing else.
+			continue
+		}
+
+		// Within front matter, if the second delimiter is found,
+		// switch to summary section.
+		if status == frontmatter {
+			add(line, n)
+			if jsonFM {
+				depth = nestingDepth(line, depth)
+			}
+			if jsonFM && depth <= 0 || !jsonFM && isFrontmatterDelim(line) { // end of front matter. Summary section begins.
+				status = summary
+				open(Summary, n+1)
+			}
+			continue
+		}
+
+		// After the summary divider, the intro starts.
+		if status == summary {
+			if isSummaryDivider(line) {
+				sec.End = n
+				status = intro
+				open(Intro, n+1)
+				continue
+			}
+			add(line, n)
+			continue
+		}
+
+		// Intro is finished when the comment end delimiter occurs.
+		// The status afterwards is not defined. Comment/code pairs might follow,
+		// or another multiline comment. Or the end of the file.
+		if status == intro || status == doc {
+			if sl.kind == blockEnd {
+				if sl.text != "" {
+					add(sl.text, n)
+				}
+				sec.End = n
+				status = none
+				continue
+			}
+			add(line, n)
+			continue
+		}
+
+		// A line comment can occur after code, after another line comment,
+		// or when no other section is active.
+		if sl.kind == lineComment && (status == none || status == code) {
+			status = comment
+			open(CommentCodePair, n)
+			add(sl.text, n)
+			continue
+		}
+
+		// While processing line comments: If still looking at a line comment,
+		// add the stripped text. Else switch into code status.
+		if status == comment {
+			if sl.kind == lineComment {
+				add(sl.text, n)
+				continue
+			}
+			status = code
+		}
+
+		// A multiline comment starts after code or outside any section.
+		if (status == code || status == none) && (sl.kind == blockStart || sl.kind == blockSingle) {
+			open(Doc, n)
+			add(sl.text, n)
+			status = doc
+			if sl.kind == blockSingle {
+				status = none
+			}
+			continue
+		}
+
+		if status == code {
+			sec.Code = append(sec.Code, sl.raw)
+			sec.End = n
+			continue
+		}
+
+		// Outside any status? Keep the line as it is.
+		if status == none {
+			if sec == nil || sec.Kind != Plain {
+				open(Plain, n)
+			}
+			add(line, n)
+		}
+	}
+	return d
+}
@@ -0,0 +1,177 @@
+package convert
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Embed tags look like image tags, with the "!" replaced by the name of the
+// embed type: `NAME[description](path)`. Each embed type has an Embedder that
+// turns the tag into HTML. A line that contains an embed tag is replaced by
+// the HTML as a whole, so an embed tag should be on a line of its own.
+//
+// The built-in embed types are:
+//
+// * HYPE: a Tumult Hype animation, from the exported HTML file.
+// * VIDEO: a video file, or the URL of a video file.
+// * ASCIINEMA: an asciinema.org recording, by its ID or URL.
+// * SVG: an SVG image, inlined into the page.
+// * IFRAME: any Web page.
+
+const embedPtrn = `\b([A-Z][A-Z0-9]*)\[([^\]]*)\]\(\s*([^)]*?)\s*\)`
+
+var embedTag = regexp.MustCompile(embedPtrn) // captures name, description, and path of an embed tag
+
+// EmbedTag is an embed tag found in the prose, with its path resolved.
+type EmbedTag struct {
+	Name        string // the embed type, like "HYPE"
+	Description string
+	Path        string // the path as written in the tag
+	// File is the path of the file in the post's media directory at the output side.
+	// It is empty if Path is a URL.
+	File string
+	// URL is the path of the file as the Web server sees it, or Path if Path is a URL.
+	URL string
+	// MediaURL is the post's media directory as the Web server sees it.
+	MediaURL string
+	// Post is the base name of the post.
+	Post string
+	// UniqueID returns id if no embed tag of the post has used it before,
+	// or else id with a numeric suffix.
+	UniqueID func(id string) string
+}
+
+// An Embedder turns an embed tag into HTML. If it cannot, it returns an
+// error. The HTML is used as a fallback after the error message then.
+type Embedder interface {
+	Embed(tag *EmbedTag) (html string, err error)
+}
+
+// EmbedFunc turns a function into an Embedder.
+type EmbedFunc func(tag *EmbedTag) (string, error)
+
+// Embed calls f(tag).
+func (f EmbedFunc) Embed(tag *EmbedTag) (string, error) {
+	return f(tag)
+}
+
+// MediaLister is implemented by Embedders whose tags refer to more media
+// files than the one in the tag. CopyMedia copies these files along with
+// the file in the tag.
+type MediaLister interface {
+	MediaFiles(path string) []string
+}
+
+// DefaultEmbeds returns the built-in embed types. To add an embed type
+// or to replace a built-in one, add it to the map and set Converter.Embeds.
+func DefaultEmbeds() map[string]Embedder {
+	return map[string]Embedder{
+		"HYPE":      hypeEmbedder{},
+		"VIDEO":     EmbedFunc(embedVideo),
+		"ASCIINEMA": EmbedFunc(embedAsciinema),
+		"SVG":       EmbedFunc(embedSVG),
+		"IFRAME":    EmbedFunc(embedIframe),
+	}
+}
+
+// embeds returns the embed types to use for rendering.
+func (c *Converter) embeds() map[string]Embedder {
+	if c.Embeds != nil {
+		return c.Embeds
+	}
+	return DefaultEmbeds()
+}
+
+// findEmbedTag returns the submatches of the first embed tag in line
+// whose name has an Embedder, or nil if there is none.
+func findEmbedTag(line string, embeds map[string]Embedder) []string {
+	for _, m := range embedTag.FindAllStringSubmatch(line, -1) {
+		if _, ok := embeds[m[1]]; ok {
+			return m
+		}
+	}
+	return nil
+}
+
+// replaceEmbedTag identifies an embed tag like `HYPE[description](animation.html)`
+// and replaces the line by the HTML from the tag's Embedder.
+//
+// It returns:
+// * out: the (possibly modified) line, or the fallback HTML if err is not nil
+// * found: true if an embed tag was found (and processed)
+// * err: an error if the HTML cannot be generated
+func (c *Converter) replaceEmbedTag(line, base string) (out string, found bool, err error) {
+	// Do not process preformatted text
+	if isPreformatted(line) {
+		return line, false, nil
+	}
+	embeds := c.embeds()
+	m := findEmbedTag(line, embeds)
+	if m == nil {
+		return line, false, nil
+	}
+	tag := &EmbedTag{Name: m[1], Description: m[2], Path: m[3], URL: m[3], MediaURL: c.extendPath("", base), Post: base}
+	if isLocal(tag.Path) {
+		tag.File = filepath.Join(c.mediaPath(base), filepath.FromSlash(tag.Path))
+		tag.URL = c.extendPath(tag.Path, base)
+	}
+	tag.UniqueID = func(id string) string {
+		if c.ids == nil {
+			c.ids = map[string]int{}
+		}
+		c.ids[id]++
+		if n := c.ids[id]; n > 1 {
+			return id + "_" + strconv.Itoa(n)
+		}
+		return id
+	}
+	out, err = embeds[tag.Name].Embed(tag)
+	return out, true, err
+}
+
+// Embed markers wrap the HTML of an embed tag in the Markdown output. The
+// start marker carries the source line of the tag, so that reverse can
+// restore the tag.
+const (
+	embedStart = "<!--gotohugo:embed "
+	embedEnd   = "<!--gotohugo:end-->"
+)
+
+// markEmbed wraps the HTML that replaces the embed tag in line into
+// embed markers.
+func markEmbed(line, html string) string {
+	if strings.Contains(line, "-->") {
+		return html // the line would end the marker comment
+	}
+	if !strings.HasSuffix(html, "\n") {
+		html += "\n"
+	}
+	return embedStart + line + " -->\n" + html + embedEnd + "\n"
+}
+
+func embedVideo(tag *EmbedTag) (string, error) {
+	return fmt.Sprintf(`<video controls preload="metadata" src="%s" title="%s"><a href="%[1]s">%[2]s</a></video>`+"\n",
+		html.EscapeString(tag.URL), html.EscapeString(tag.Description)), nil
+}
+
+const asciinemaURL = "https://asciinema.org/a/"
+
+// embedAsciinema embeds an asciinema.org recording.
+// The path is the ID of the recording or its URL.
+func embedAsciinema(tag *EmbedTag) (string, error) {
+	id := strings.TrimSuffix(strings.TrimPrefix(tag.Path, asciinemaURL), "/")
+	if id == "" || strings.ContainsAny(id, `/:"`) {
+		return "", fmt.Errorf("invalid asciinema recording  %s", tag.Path)
+	}
+	return fmt.Sprintf(`<script id="asciicast-%s" src="%s%[1]s.js" async></script>`+"\n",
+		html.EscapeString(id), asciinemaURL), nil
+}
+
+func embedIframe(tag *EmbedTag) (string, error) {
+	return fmt.Sprintf(`<iframe src="%s" title="%s" loading="lazy" allowfullscreen></iframe>`+"\n",
+		html.EscapeString(tag.URL), html.EscapeString(tag.Description)), nil
+}
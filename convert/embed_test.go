@@ -0,0 +1,27 @@
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCustomEmbed(t *testing.T) {
+	c := newTestConverter("post")
+	c.Embeds = DefaultEmbeds()
+	c.Embeds["GIST"] = EmbedFunc(func(tag *EmbedTag) (string, error) {
+		return `<script src="https://gist.github.com/` + tag.Path + `.js"></script>` + "\n", nil
+	})
+	src := "/*\n+++\n+++\nSummary\n<!--more-->\nGIST[A gist](user/1234)\n\n![Image](image.png)\n*/\n"
+	var out bytes.Buffer
+	if err := c.Convert(strings.NewReader(src), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `<script src="https://gist.github.com/user/1234.js"></script>`) {
+		t.Errorf("GIST tag not embedded:\n%s", out.String())
+	}
+	refs := c.mediaRefs(Parse([]byte(src)))
+	if len(refs) != 2 || refs[0].Path != "user/1234" || refs[0].Embed != "GIST" || refs[1].Path != "image.png" {
+		t.Errorf("unexpected media refs %+v", refs)
+	}
+}
@@ -0,0 +1,117 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Enrichment adds fields to the front matter that are tedious to maintain
+// by hand:
+//
+// * lastmod: the time of the last change of the source file.
+// * wordCount: the number of words in the prose of the post.
+// * readingTime: the reading time in minutes, computed like Hugo does.
+// * codeLines: the number of non-blank code lines.
+//
+// Fields that exist already get the new value. New fields are added at
+// the top of the front matter. The rest of the front matter, including
+// comments, stays as it is.
+
+// wordsPerMinute is the reading speed that Hugo assumes.
+const wordsPerMinute = 213
+
+// Stats are the numbers that Enrich adds to the front matter.
+type Stats struct {
+	Words       int
+	ReadingTime int // in minutes
+	CodeLines   int
+}
+
+// DocumentStats counts the words in the prose sections of d,
+// and the non-blank lines of code.
+func DocumentStats(d *Document) Stats {
+	var st Stats
+	for _, s := range d.Sections {
+		switch s.Kind {
+		case Summary, Intro, Doc, CommentCodePair:
+			for _, line := range s.Text {
+				st.Words += len(strings.Fields(line))
+			}
+		}
+		code := s.Code
+		if s.Kind == Plain {
+			code = s.Text
+		}
+		for _, line := range code {
+			if strings.TrimSpace(line) != "" {
+				st.CodeLines++
+			}
+		}
+	}
+	st.ReadingTime = (st.Words + wordsPerMinute - 1) / wordsPerMinute
+	return st
+}
+
+// Enrich sets lastmod, wordCount, readingTime, and codeLines in the front
+// matter of d. If lastmod is the zero time, lastmod is left as it is.
+func Enrich(d *Document, lastmod time.Time) error {
+	if len(d.Sections) == 0 || d.Sections[0].Kind != FrontMatter {
+		return ErrMissingFrontMatter
+	}
+	fm := d.Sections[0]
+	if _, err := decodeFrontMatter(fm); err != nil {
+		return err
+	}
+	st := DocumentStats(d)
+	fields := []struct{ key, value string }{
+		{"lastmod", strconv.Quote(lastmod.Format(time.RFC3339))},
+		{"wordCount", strconv.Itoa(st.Words)},
+		{"readingTime", strconv.Itoa(st.ReadingTime)},
+		{"codeLines", strconv.Itoa(st.CodeLines)},
+	}
+	if lastmod.IsZero() {
+		fields = fields[1:]
+	}
+	// New fields go to the top, so insert them in reverse order.
+	for i := len(fields) - 1; i >= 0; i-- {
+		setFrontMatterField(fm, fields[i].key, fields[i].value)
+	}
+	return nil
+}
+
+// LastModified returns the time of the last change of filename. If the
+// file is under Git version control and has no uncommitted changes, this
+// is the time of the last commit that changed the file. Otherwise, it is
+// the modification time of the file.
+func LastModified(filename string) (time.Time, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot stat  %s: %w", filename, err)
+	}
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	status, err := git("status", "--porcelain", "--", base)
+	if err != nil || status != "" {
+		return info.ModTime(), nil // not in a repository, untracked, or changed
+	}
+	commit, err := git("log", "-1", "--format=%cI", "--", base)
+	if err != nil || commit == "" {
+		return info.ModTime(), nil
+	}
+	t, err := time.Parse(time.RFC3339, commit)
+	if err != nil {
+		return info.ModTime(), nil
+	}
+	return t, nil
+}
@@ -0,0 +1,59 @@
+package convert
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnrich(t *testing.T) {
+	const body = "\nOne two three.\n\n<!--more-->\n\nFour five.\n*/\n\n// Six.\npackage main\n\nfunc main() {\n}\n"
+	lastmod := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct{ name, frontMatter string }{
+		{"toml", "+++\ntitle = \"T\"\nwordcount = 1 # stale\n[params]\ncodeLines = 99\n+++"},
+		{"yaml", "---\ntitle: T\nparams:\n  wordCount: 1\n---"},
+		{"json", "{\n  \"title\": \"T\",\n  \"wordCount\": 1\n}"},
+		{"empty json", "{\n}"},
+	}
+	for _, test := range tests {
+		d := Parse([]byte("/*\n" + test.frontMatter + body))
+		if err := Enrich(d, lastmod); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		data, err := decodeFrontMatter(d.Sections[0])
+		if err != nil {
+			t.Errorf("%s: enriched front matter is invalid: %v\n%s", test.name, err, verbatim(d.Sections[0].Text))
+			continue
+		}
+		want := map[string]interface{}{"lastmod": "2024-03-01T12:00:00Z", "wordCount": 6, "readingTime": 1, "codeLines": 3}
+		for key, value := range want {
+			if fmt.Sprint(data[key]) != fmt.Sprint(value) {
+				t.Errorf("%s: %s = %v, want %v\n%s", test.name, key, data[key], value, verbatim(d.Sections[0].Text))
+			}
+		}
+	}
+}
+
+// TestEnrichWarning asserts that ConvertFile reports front matter that
+// cannot be enriched, and converts the file without the enrichment.
+func TestEnrichWarning(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "post.go"):            "/*\n+++\ntitle = \"T\"\ndraft = yes\n+++\n*/\n",
+		filepath.Join(dir, "out", "placeholder"): "",
+	})
+	var warnings []string
+	c := &Converter{OutDir: filepath.Join(dir, "out"), EnrichFrontMatter: true, Warn: func(err error) { warnings = append(warnings, err.Error()) }}
+	if err := c.ConvertFile(filepath.Join(dir, "post.go")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(warnings, "\n"), "cannot enrich") {
+		t.Errorf("got warnings %v, want an enrichment error", warnings)
+	}
+	if !isFile(filepath.Join(dir, "out", "post", "index.md")) {
+		t.Error("no page written")
+	}
+}
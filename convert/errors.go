@@ -0,0 +1,91 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Problems in the source, like a missing Hype file, are reported as *Error
+// values that carry the source line. By default, the Converter embeds a
+// warning into the rendered page and continues. Front matter problems are
+// only passed to Warn, as a message would break the front matter. In strict
+// mode, the Converter aborts the conversion and returns the error instead.
+
+// ErrMissingFrontMatter indicates that the source contains no front matter.
+var ErrMissingFrontMatter = errors.New("missing front matter")
+
+// Error is a problem at a specific line of the source.
+type Error struct {
+	Line int // starting at 1
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HypeFileError indicates that the Hype animation HTML that
+// a HYPE tag refers to cannot be read.
+type HypeFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *HypeFileError) Error() string {
+	return fmt.Sprintf("no Hype file found at  %s . Please run gotohugo again after creating the Hype animation HTML export.: %v", e.Path, e.Err)
+}
+
+func (e *HypeFileError) Unwrap() error {
+	return e.Err
+}
+
+// MissingMediaError indicates that an image or Hype animation that the
+// post refers to exists neither in the source media folder nor in the
+// media directory at the output side.
+type MissingMediaError struct {
+	Path string // as written in the reference
+}
+
+func (e *MissingMediaError) Error() string {
+	return fmt.Sprintf("media file  %s not found", e.Path)
+}
+
+// UnusedMediaError indicates a file in the source media folder
+// that the post does not refer to.
+type UnusedMediaError struct {
+	Path string
+}
+
+func (e *UnusedMediaError) Error() string {
+	return fmt.Sprintf("media file  %s is not used", e.Path)
+}
+
+// problemMark ends the messages that are embedded into the page, so that
+// reverse can drop them. The page does not show it.
+const problemMark = " <!--gotohugo:problem-->"
+
+// problem handles an error at the given source line. In strict mode,
+// it returns the error. Otherwise, it passes the error to Warn and returns
+// the error message for embedding into the page.
+func (c *Converter) problem(line int, err error) (string, error) {
+	e := &Error{Line: line, Err: err}
+	if c.Strict {
+		return "", e
+	}
+	c.warn(e)
+	return err.Error() + problemMark, nil // remind the developer by adding the message to the rendered page
+}
+
+// warn passes err to Warn, or writes it to the standard logger.
+func (c *Converter) warn(err error) {
+	if c.Warn != nil {
+		c.Warn(err)
+	} else {
+		log.Println(err) // notify the developer via shell
+	}
+}
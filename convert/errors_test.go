@@ -0,0 +1,42 @@
+package convert
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStrict asserts that in strict mode, a problem aborts the conversion
+// with an *Error at the line of the problem, and that the existing output
+// stays untouched.
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+	}{
+		{"missing Hype file", "/*\n+++\ntitle = \"Strict\"\n+++\nSummary\n<!--more-->\nIntro\n\nHYPE[Missing](missing.html)\n*/\n", 9},
+		{"missing front matter", "// A comment.\npackage main\n", 1},
+		{"front matter syntax", "/*\n+++\ntitle = \"Strict\"\ndraft = yes\n+++\n*/\n", 4},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		page := filepath.Join(dir, "out", "strict", "index.md")
+		writeFiles(t, map[string]string{
+			filepath.Join(dir, "strict.go"): test.src,
+			page:                            "existing output",
+		})
+		c := &Converter{OutDir: filepath.Join(dir, "out"), Strict: true, Warn: func(err error) { t.Error(err) }}
+		err := c.ConvertFile(filepath.Join(dir, "strict.go"))
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: want *Error, got %v", test.name, err)
+		} else if e.Line != test.line {
+			t.Errorf("%s: got line %d, want %d", test.name, e.Line, test.line)
+		}
+		if out, err := os.ReadFile(page); err != nil || string(out) != "existing output" {
+			t.Errorf("%s: output changed to %q (%v)", test.name, out, err)
+		}
+	}
+}
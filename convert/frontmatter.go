@@ -0,0 +1,187 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Hugo accepts front matter in three syntaxes: TOML between `+++` lines,
+// YAML between `---` lines, and a JSON object between a `{` line and
+// the matching `}`. gotohugo passes the front matter through as it is,
+// but it parses it to report syntax errors before Hugo does.
+
+// yamlErrorLine matches the line number in the error messages of the YAML decoder.
+var yamlErrorLine = regexp.MustCompile(`line ([0-9]+): (.*)`)
+
+// isJSONStart returns true if line opens JSON front matter.
+func isJSONStart(line string) bool {
+	return strings.TrimSpace(line) == "{"
+}
+
+// nestingDepth returns the nesting depth of objects and arrays after line,
+// given the depth before line. Brackets within strings do not count.
+func nestingDepth(line string, depth int) int {
+	inString, escaped := false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case !inString && (r == '{' || r == '['):
+			depth++
+		case !inString && (r == '}' || r == ']'):
+			depth--
+		}
+	}
+	return depth
+}
+
+// frontMatterSyntax returns the syntax of the front matter section s:
+// "toml", "yaml", or "json".
+func frontMatterSyntax(s *Section) string {
+	if len(s.Text) > 0 && isJSONStart(s.Text[0]) {
+		return "json"
+	}
+	if len(s.Text) > 0 && strings.TrimSpace(s.Text[0]) == "---" {
+		return "yaml"
+	}
+	return "toml"
+}
+
+// decodeData parses src in the given syntax.
+func decodeData(syntax string, src []byte) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	var err error
+	switch syntax {
+	case "toml":
+		err = toml.Unmarshal(src, &data)
+	case "yaml":
+		err = yaml.Unmarshal(src, &data)
+	case "json":
+		err = json.Unmarshal(src, &data)
+	default:
+		err = fmt.Errorf("unknown syntax %q", syntax)
+	}
+	return data, err
+}
+
+// decodeFrontMatter parses the front matter section s. A syntax error
+// is returned as *Error with the source line of the error, as far as
+// the decoder reports it.
+func decodeFrontMatter(s *Section) (map[string]interface{}, error) {
+	syntax := frontMatterSyntax(s)
+	lines, first := s.Text, s.Start // the content and its first source line
+	if syntax != "json" && len(lines) >= 2 {
+		lines, first = lines[1:len(lines)-1], first+1
+	}
+	src := []byte(strings.Join(lines, "\n") + "\n")
+	data, err := decodeData(syntax, src)
+	if err == nil {
+		return data, nil
+	}
+
+	line, msg := 0, err.Error() // line within src, starting at 1
+	var tomlErr toml.ParseError
+	var jsonErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tomlErr):
+		line, msg = tomlErr.Position.Line, tomlErr.Message
+	case errors.As(err, &jsonErr):
+		line = bytes.Count(src[:jsonErr.Offset], []byte("\n")) + 1
+	case errors.As(err, &typeErr):
+		line = bytes.Count(src[:typeErr.Offset], []byte("\n")) + 1
+	case syntax == "yaml":
+		if m := yamlErrorLine.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+	}
+	if line < 1 || line > len(lines) {
+		line = 1
+	}
+	return nil, &Error{Line: first + line - 1, Err: fmt.Errorf("invalid %s front matter: %s", strings.ToUpper(syntax), msg)}
+}
+
+// setFrontMatterField sets the top-level field key of the front matter s
+// to value, which must be a valid literal in TOML, YAML, and JSON. The
+// old value is replaced, even if it spans several lines. A new field is
+// inserted right after the opening delimiter.
+func setFrontMatterField(s *Section, key, value string) {
+	syntax := frontMatterSyntax(s)
+	field := regexp.MustCompile(`(?i)^(\s*)"?` + regexp.QuoteMeta(key) + `"?\s*[=:]`)
+	depth := 0
+	for i := 1; i < len(s.Text)-1; i++ {
+		line := s.Text[i]
+		if syntax == "toml" && depth == 0 && strings.HasPrefix(strings.TrimSpace(line), "[") {
+			break // the fields after a table header belong to the table
+		}
+		top := syntax == "yaml" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") ||
+			syntax != "yaml" && depth == 0
+		if m := field.FindStringSubmatch(line); top && m != nil {
+			end := fieldEnd(syntax, s.Text, i)
+			comma := strings.HasSuffix(strings.TrimSpace(s.Text[end-1]), ",")
+			line = frontMatterField(syntax, m[1], key, value, comma)
+			s.Text = append(s.Text[:i], append([]string{line}, s.Text[end:]...)...)
+			return
+		}
+		if syntax != "yaml" {
+			depth = nestingDepth(line, depth)
+		}
+	}
+	comma := syntax == "json" && len(s.Text) > 1 && strings.TrimSpace(s.Text[1]) != "}"
+	line := frontMatterField(syntax, "", key, value, comma)
+	if syntax == "json" {
+		line = "  " + line
+	}
+	s.Text = append(s.Text[:1], append([]string{line}, s.Text[1:]...)...)
+}
+
+// fieldEnd returns the index of the first line after the field that
+// starts at lines[i]. A value can continue on the following lines if it
+// is an array or an object, or in YAML, if the lines are indented or
+// list items.
+func fieldEnd(syntax string, lines []string, i int) int {
+	end := i + 1
+	if syntax == "yaml" {
+		for end < len(lines)-1 && (strings.HasPrefix(lines[end], " ") || strings.HasPrefix(lines[end], "\t") ||
+			strings.HasPrefix(lines[end], "- ") || lines[end] == "-") {
+			end++
+		}
+		return end
+	}
+	depth := nestingDepth(lines[i], 0)
+	for depth > 0 && end < len(lines)-1 {
+		depth = nestingDepth(lines[end], depth)
+		end++
+	}
+	return end
+}
+
+// frontMatterField formats a field in the given syntax.
+func frontMatterField(syntax, indent, key, value string, comma bool) string {
+	var line string
+	switch syntax {
+	case "toml":
+		line = key + " = " + value
+	case "yaml":
+		line = key + ": " + value
+	case "json":
+		line = strconv.Quote(key) + ": " + value
+	}
+	if comma {
+		line += ","
+	}
+	return indent + line
+}
@@ -0,0 +1,32 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInvalidFrontMatter(t *testing.T) {
+	const body = "\nSummary.\n\n<!--more-->\n*/\n"
+	tests := []struct {
+		name, src string
+		line      int // 0 for valid front matter
+	}{
+		{"toml", "/*\n+++\ntitle = \"ok\"\ndate = 2024-03-01\n+++\n", 0},
+		{"toml error", "/*\n+++\ntitle = \"ok\"\ndraft = yes\n+++\n", 4},
+		{"yaml", "/*\n---\ntitle: ok\ntags: [a, b]\n---\n", 0},
+		{"yaml error", "/*\n---\ntitle: ok\ntags: a: b\n---\n", 4},
+		{"json", "/*\n{\n  \"title\": \"ok\",\n  \"tags\": [\"a\"]\n}\n", 0},
+		{"json error", "/*\n{\n  \"title\": \"ok\"\n  \"tags\": [\"a\"]\n}\n", 4},
+	}
+	for _, test := range tests {
+		diags := Check([]byte(test.src + body))
+		switch {
+		case test.line == 0 && len(diags) > 0:
+			t.Errorf("%s: unexpected diagnostics %v", test.name, diags)
+		case test.line > 0 && (len(diags) != 1 || !strings.Contains(diags[0].Msg, "front matter")):
+			t.Errorf("%s: got %v, want a front matter error", test.name, diags)
+		case test.line > 0 && diags[0].Line != test.line:
+			t.Errorf("%s: got error at line %d, want line %d: %v", test.name, diags[0].Line, test.line, diags[0])
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// The HTML output is a self-contained page for previewing a post without
+// running Hugo. Comments appear in a column left to the code, and doc
+// sections are centered in a single column. If the viewport is too narrow,
+// comment and code collapse into a single column.
+
+// markdown converts the prose to HTML. Raw HTML like the Hype snippets
+// is passed through.
+var markdown = goldmark.New(goldmark.WithRendererOptions(gmhtml.WithUnsafe()))
+
+const pageCSS = `
+body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; color: #222; background: #fff; }
+.gotohugo { max-width: 90em; margin: 0 auto; padding: 1em; }
+.doc { max-width: 36em; margin: 0 auto; padding: 0 1em; }
+.ccpair { display: flex; border-top: 1px solid #eee; }
+.ccpair .comment { flex: 0 0 35%; box-sizing: border-box; padding: 0 1.5em 0 1em; }
+.ccpair .code { flex: 1 1 65%; min-width: 0; background: #f8f8f8; }
+.plain { max-width: 60em; margin: 0 auto; }
+pre { margin: 0; padding: 1em; overflow-x: auto; font-size: 0.9em; line-height: 1.4; }
+code { font-family: Menlo, Consolas, "DejaVu Sans Mono", monospace; }
+img { max-width: 100%; }
+.kw { color: #a626a4; font-weight: bold; }
+.str { color: #50a14f; }
+.num { color: #986801; }
+.com { color: #a0a1a7; font-style: italic; }
+.fn { color: #4078f2; }
+@media (max-width: 60em) {
+	.ccpair { display: block; }
+	.ccpair .comment { padding: 0 1em; }
+}
+`
+
+const pageTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+<main class="gotohugo">
+{{range .Sections}}{{if eq .Class "ccpair"}}<div class="ccpair">
+<div class="comment">
+{{.Text}}</div>
+<div class="code"><pre><code class="language-go">{{.Code}}</code></pre></div>
+</div>
+{{else if eq .Class "plain"}}<div class="plain"><pre><code class="language-go">{{.Code}}</code></pre></div>
+{{else}}<div class="{{.Class}}">
+{{.Text}}</div>
+{{end}}{{end}}</main>
+</body>
+</html>
+`
+
+var page = template.Must(template.New("page").Parse(pageTemplate))
+
+// htmlSection is a section prepared for the page template.
+type htmlSection struct {
+	Class string
+	Text  template.HTML
+	Code  template.HTML
+}
+
+// markdownToHTML converts Markdown text to HTML.
+func markdownToHTML(md string) (template.HTML, error) {
+	var out bytes.Buffer
+	if err := markdown.Convert([]byte(md), &out); err != nil {
+		return "", fmt.Errorf("cannot convert Markdown to HTML: %w", err)
+	}
+	return template.HTML(out.String()), nil
+}
+
+// proseHTML processes the prose of s and converts it to HTML.
+func (c *Converter) proseHTML(s *Section, base string) (template.HTML, error) {
+	md, err := c.prose(s, base)
+	if err != nil {
+		return "", err
+	}
+	return markdownToHTML(md)
+}
+
+// highlight wraps keywords, literals, and comments of the Go code
+// into span elements for syntax highlighting. Code that cannot be
+// tokenized is passed through escaped.
+func highlight(code string) template.HTML {
+	src := []byte(code)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var out strings.Builder
+	prev := 0
+	prevTok := token.ILLEGAL
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// Skip semicolons that the scanner inserts automatically at line ends.
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		start := file.Offset(pos)
+		if lit == "" {
+			lit = tok.String()
+		}
+		end := start + len(lit)
+		if start < prev || end > len(src) {
+			continue
+		}
+		out.WriteString(html.EscapeString(code[prev:start]))
+		class := ""
+		switch {
+		case tok.IsKeyword():
+			class = "kw"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "str"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "num"
+		case tok == token.COMMENT:
+			class = "com"
+		case tok == token.IDENT && prevTok == token.FUNC:
+			class = "fn"
+		}
+		text := html.EscapeString(code[start:end])
+		if class != "" {
+			text = `<span class="` + class + `">` + text + `</span>`
+		}
+		out.WriteString(text)
+		prev = end
+		prevTok = tok
+	}
+	out.WriteString(html.EscapeString(code[prev:]))
+	return template.HTML(out.String())
+}
+
+// title returns the top-level title of the front matter, or "" if there
+// is none. Syntax errors are reported by Render, so title ignores them.
+func title(d *Document) string {
+	if len(d.Sections) == 0 || d.Sections[0].Kind != FrontMatter {
+		return ""
+	}
+	data, err := decodeFrontMatter(d.Sections[0])
+	if err != nil {
+		return ""
+	}
+	t, _ := data["title"].(string)
+	return t
+}
+
+// renderHTML turns the sections of d into a standalone HTML page.
+func (c *Converter) renderHTML(d *Document, base string) (string, error) {
+	data := struct {
+		Title    string
+		CSS      template.CSS
+		Sections []htmlSection
+	}{
+		Title: title(d),
+		CSS:   template.CSS(pageCSS),
+	}
+	if data.Title == "" {
+		data.Title = base
+	}
+
+	for _, s := range d.Sections {
+		var hs htmlSection
+		var err error
+		switch s.Kind {
+		case FrontMatter:
+			continue
+		case Summary:
+			hs.Class = "doc summary"
+			hs.Text, err = markdownToHTML(verbatim(s.Text))
+		case Intro, Doc:
+			hs.Class = "doc"
+			hs.Text, err = c.proseHTML(s, base)
+		case CommentCodePair:
+			hs.Class = "ccpair"
+			hs.Text, err = c.proseHTML(s, base)
+			hs.Code = highlight(strings.TrimRight(verbatim(s.Code), "\n"))
+		case Plain:
+			code := strings.Trim(verbatim(s.Text), "\n")
+			if code == "" {
+				continue
+			}
+			hs.Class = "plain"
+			hs.Code = highlight(code)
+		}
+		if err != nil {
+			return "", err
+		}
+		data.Sections = append(data.Sections, hs)
+	}
+
+	var out strings.Builder
+	if err := page.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("cannot render HTML page: %w", err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,143 @@
+package convert
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct{ code, want string }{
+		{"func f() {}", `<span class="kw">func</span> <span class="fn">f</span>() {}`},
+		{`s := "<b>" + 'x'`, `s := <span class="str">&#34;&lt;b&gt;&#34;</span> + <span class="str">&#39;x&#39;</span>`},
+		{"if a < 1 && b > 2.5 {", `<span class="kw">if</span> a &lt; <span class="num">1</span> &amp;&amp; b &gt; <span class="num">2.5</span> {`},
+		{"x++ // a <tag>", `x++ <span class="com">// a &lt;tag&gt;</span>`},
+	}
+	for _, test := range tests {
+		if got := string(highlight(test.code)); got != test.want {
+			t.Errorf("highlight(%q):\ngot  %s\nwant %s", test.code, got, test.want)
+		}
+	}
+}
+
+// TestRenderHTML checks the structure of the page: one div per section
+// with the class of the section, comment and code side by side in
+// ccpair divs, and escaped code.
+func TestRenderHTML(t *testing.T) {
+	const code = "func less(a, b int) bool {\n\treturn a < b && \"<br>\" != \"\"\n}"
+	src := "/*\n+++\ntitle = \"A < B\"\n+++\nSummary\n<!--more-->\nIntro\n*/\n\npackage main\n\n" +
+		"// Compare `a < b`.\n" + code + "\n\n/* A doc section. */\n"
+	c := newTestConverter("page")
+	c.Format = HTML
+	var out bytes.Buffer
+	if err := c.Convert(strings.NewReader(src), &out); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := html.Parse(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var classes []string
+	var comment, codeText string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "title" && nodeText(n) != "A < B" {
+			t.Errorf("got title %q", nodeText(n))
+		}
+		if n.Type == html.ElementNode && n.Data == "div" && n.Parent.Data == "main" {
+			class := nodeAttr(n, "class")
+			classes = append(classes, class)
+			if class == "ccpair" {
+				for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+					switch nodeAttr(ch, "class") {
+					case "comment":
+						comment = strings.TrimSpace(nodeText(ch))
+					case "code":
+						codeText = nodeText(ch)
+					}
+				}
+			}
+		}
+		if n.Type == html.ElementNode && n.Data == "br" {
+			t.Error("code not escaped: <br> element in the page")
+		}
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch)
+		}
+	}
+	walk(doc)
+
+	if want := []string{"doc summary", "doc", "plain", "ccpair", "doc"}; !reflect.DeepEqual(classes, want) {
+		t.Errorf("got sections %q, want %q", classes, want)
+	}
+	if comment != "Compare a < b." {
+		t.Errorf("got comment %q", comment)
+	}
+	if codeText != code {
+		t.Errorf("got code %q, want %q", codeText, code)
+	}
+}
+
+// TestTitle asserts that the page title is the decoded top-level title
+// of the front matter, and the base name if there is none.
+func TestTitle(t *testing.T) {
+	tests := []struct{ name, fm, want string }{
+		{"toml", "+++\ntitle = \"Say \\\"hi\\\"\"\n+++", `Say "hi"`},
+		{"yaml", "---\ntitle: 'It''s here'\n---", "It's here"},
+		{"json", "{\n\"title\": \"A \\u0026 B\"\n}", "A & B"},
+		{"nested title", "+++\ndate = 2020-01-01\n[params]\ntitle = \"Nested\"\n+++", "page"},
+		{"no title", "+++\ndraft = true\n+++", "page"},
+	}
+	for _, test := range tests {
+		c := newTestConverter("page")
+		c.Format = HTML
+		var out bytes.Buffer
+		if err := c.Convert(strings.NewReader("/*\n"+test.fm+"\nSummary\n<!--more-->\n*/\n"), &out); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		doc, err := html.Parse(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.ElementNode && n.Data == "title" {
+				got = nodeText(n)
+			}
+			for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+				walk(ch)
+			}
+		}
+		walk(doc)
+		if got != test.want {
+			t.Errorf("%s: got title %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// nodeText returns the text content of n.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var s string
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		s += nodeText(ch)
+	}
+	return s
+}
+
+// nodeAttr returns the value of the attribute key of n.
+func nodeAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
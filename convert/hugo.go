@@ -0,0 +1,115 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A Hugo site keeps its content and static files in directories that the
+// site config can change, via `contentDir` and `staticDir`. Posts go into
+// a section of the content dir, "post" by default. The section can be set
+// in the site config as well, through `params.gotohugo.section`:
+//
+//	[params.gotohugo]
+//	section = "tutorials"
+
+// hugoConfigFiles are the names of the site config files, in the order
+// that Hugo looks for them.
+var hugoConfigFiles = []string{
+	"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json",
+	"config.toml", "config.yaml", "config.yml", "config.json",
+}
+
+// HugoSite is the directory layout of a Hugo site.
+type HugoSite struct {
+	Root       string // the root directory of the site
+	Config     string // the site config file, or "" if there is none
+	ContentDir string // relative to Root
+	StaticDir  string // relative to Root
+	Section    string // the section of the content dir that takes the posts
+}
+
+// ReadHugoSite reads the site config of the Hugo site at root. Settings
+// that the config does not contain get Hugo's defaults, and a site
+// without a config file gets the defaults altogether.
+func ReadHugoSite(root string) (*HugoSite, error) {
+	site := &HugoSite{Root: root, ContentDir: "content", StaticDir: "static", Section: "post"}
+	for _, dir := range []string{root, filepath.Join(root, "config", "_default")} {
+		for _, name := range hugoConfigFiles {
+			path := filepath.Join(dir, name)
+			if !isFile(path) {
+				continue
+			}
+			site.Config = path
+			return site, site.read(path)
+		}
+	}
+	return site, nil
+}
+
+// read takes the settings from the config file at path.
+func (s *HugoSite) read(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read Hugo config  %s: %w", path, err)
+	}
+	syntax := strings.TrimPrefix(filepath.Ext(path), ".")
+	if syntax == "yml" {
+		syntax = "yaml"
+	}
+	config, err := decodeData(syntax, src)
+	if err != nil {
+		return fmt.Errorf("cannot parse Hugo config  %s: %w", path, err)
+	}
+	if dir := configString(config, "contentDir"); dir != "" {
+		s.ContentDir = dir
+	}
+	if dir := configString(config, "staticDir"); dir != "" {
+		s.StaticDir = dir
+	}
+	if section := configString(config, "params", "gotohugo", "section"); section != "" {
+		s.Section = section
+	}
+	return nil
+}
+
+// configString looks up a string value along the keys. Like Hugo,
+// it ignores the case of the keys. For a list of strings, like
+// a list of static dirs, it returns the first one.
+func configString(config map[string]interface{}, keys ...string) string {
+	var value interface{} = config
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = nil
+		for k, v := range m {
+			if strings.EqualFold(k, key) {
+				value = v
+				break
+			}
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			s, _ := v[0].(string)
+			return s
+		}
+	}
+	return ""
+}
+
+// Configure makes c write posts into the section of the site, and
+// media files into `<staticDir>/media`.
+func (s *HugoSite) Configure(c *Converter) {
+	c.OutDir = s.Root
+	c.PostDir = filepath.Join(s.ContentDir, s.Section)
+	c.MediaDir = filepath.Join(s.StaticDir, "media") // media dir as Hugo sees it
+	c.PublicMediaDir = "media"                       // media dir as the Web server sees it
+}
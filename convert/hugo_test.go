@@ -0,0 +1,70 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadHugoSite(t *testing.T) {
+	tests := []struct {
+		file, config string
+		want         HugoSite
+	}{
+		{"", "", HugoSite{ContentDir: "content", StaticDir: "static", Section: "post"}},
+		{"config.toml", "contentDir = \"src\"\n[params.gotohugo]\nsection = \"tutorials\"\n",
+			HugoSite{ContentDir: "src", StaticDir: "static", Section: "tutorials"}},
+		{"hugo.yaml", "staticdir:\n  - assets\n  - more\nparams:\n  gotohugo:\n    section: go\n",
+			HugoSite{ContentDir: "content", StaticDir: "assets", Section: "go"}},
+		{"config/_default/hugo.json", `{"contentDir": "pages"}`,
+			HugoSite{ContentDir: "pages", StaticDir: "static", Section: "post"}},
+	}
+	for _, test := range tests {
+		root := t.TempDir()
+		test.want.Root = root
+		if test.file != "" {
+			test.want.Config = filepath.Join(root, filepath.FromSlash(test.file))
+			writeFiles(t, map[string]string{test.want.Config: test.config})
+		}
+		site, err := ReadHugoSite(root)
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if *site != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.file, *site, test.want)
+		}
+	}
+}
+
+// TestHugoMedia asserts that in a Hugo site, the page refers to the media
+// files at the URL where Hugo serves the copies.
+func TestHugoMedia(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "site")
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "post", "post.go"):               "/*\n+++\n+++\nSummary\n<!--more-->\n![img](pic.png)\n*/\n",
+		filepath.Join(dir, "post", "post", "pic.png"):       "png",
+		filepath.Join(root, "content", "post", "_index.md"): "",
+	})
+	site, err := ReadHugoSite(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Converter{CopyMedia: true, Warn: func(err error) { t.Error(err) }}
+	site.Configure(c)
+	if err := c.ConvertFile(filepath.Join(dir, "post", "post.go")); err != nil {
+		t.Fatal(err)
+	}
+	if !isFile(filepath.Join(root, "static", "media", "post", "pic.png")) {
+		t.Error("pic.png not copied to static/media/post")
+	}
+	page, err := os.ReadFile(filepath.Join(root, "content", "post", "post", "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), "![img](/media/post/pic.png)") {
+		t.Errorf("page does not refer to /media/post/pic.png:\n%s", page)
+	}
+}
@@ -0,0 +1,207 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// A Hype animation exported as HTML5 consists of an HTML file and a
+// `<name>.hyperesources` folder. The HTML file contains a container div
+// (`<div id="<name>_hype_container" class="HYPE_document" ...>`) and
+// a script that loads the animation from the resources folder into the
+// container. Older exports mark these lines with `<!-- copy these lines to
+// your document: -->` and `<!-- end copy -->`, newer ones do not, so
+// gotohugo reads the HTML with a tokenizer and looks for the container
+// and the loader script instead.
+
+const hypeNoscript = "<noscript class=\"nohype\"><em>Please enable JavaScript to view the animation.</em></noscript>\n"
+
+// hypeEmbedder embeds the HTML snippet generated by [Tumult Hype](http://tumult.com)
+// through the "Export as HTML5 > Also save .html file" option.
+type hypeEmbedder struct{}
+
+func (hypeEmbedder) Embed(tag *EmbedTag) (string, error) {
+	if tag.File == "" {
+		return hypeNoscript, fmt.Errorf("HYPE tag needs a local file, not  %s", tag.Path)
+	}
+	out, err := getHTMLSnippet(tag.File, tag.MediaURL, tag.UniqueID)
+	return out + hypeNoscript, err
+}
+
+// MediaFiles adds the resources folder of the animation.
+func (hypeEmbedder) MediaFiles(path string) []string {
+	return []string{Base(path) + ".hyperesources"}
+}
+
+// isHypeContainer returns true if the attributes belong to
+// the container div of a Hype animation.
+func isHypeContainer(attrs []html.Attribute) bool {
+	for _, a := range attrs {
+		switch a.Key {
+		case "id":
+			if strings.HasSuffix(a.Val, "_hype_container") {
+				return true
+			}
+		case "class":
+			for _, class := range strings.Fields(a.Val) {
+				if class == "HYPE_document" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isHypeLoader returns true if the attributes belong to
+// a script that loads a Hype animation.
+func isHypeLoader(attrs []html.Attribute) bool {
+	for _, a := range attrs {
+		if a.Key == "src" && strings.Contains(a.Val, ".hyperesources/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isRelativeURL returns true for URLs that refer to a file
+// relative to the Hype HTML file.
+func isRelativeURL(url string) bool {
+	return url != "" && !strings.Contains(url, ":") && !strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "#")
+}
+
+// extendURLs prefixes the relative resource URLs in the attributes
+// with mediaURL, the post's media directory as the Web server sees it.
+func extendURLs(attrs []html.Attribute, mediaURL string) {
+	for i, a := range attrs {
+		switch a.Key {
+		case "src", "href", "poster", "data":
+			if isRelativeURL(a.Val) {
+				attrs[i].Val = mediaURL + "/" + strings.TrimPrefix(a.Val, "./")
+			}
+		case "style":
+			attrs[i].Val = extendStyleURLs(a.Val, mediaURL)
+		}
+	}
+}
+
+// extendStyleURLs prefixes the relative `url(...)` references in a style attribute.
+func extendStyleURLs(style, mediaURL string) string {
+	var out strings.Builder
+	for {
+		i := strings.Index(style, "url(")
+		if i < 0 {
+			break
+		}
+		i += len("url(")
+		out.WriteString(style[:i])
+		style = style[i:]
+		quote := ""
+		if strings.HasPrefix(style, `"`) || strings.HasPrefix(style, "'") {
+			quote, style = style[:1], style[1:]
+		}
+		out.WriteString(quote)
+		if isRelativeURL(strings.SplitN(style, ")", 2)[0]) {
+			out.WriteString(mediaURL + "/")
+		}
+	}
+	out.WriteString(style)
+	return out.String()
+}
+
+// getHTMLSnippet opens the file determined by `path`, and extracts the container
+// divs and the loader scripts of the Hype animations in the file, with all
+// resource URLs extended by mediaURL. uniqueID makes the container ids unique
+// within the post. It returns the HTML snippet, or a *HypeFileError if
+// the file cannot be read.
+//
+// The loader script finds the container by its id, so an animation whose
+// container id is already in use would not play. In this case, getHTMLSnippet
+// returns an empty snippet and an error.
+func getHTMLSnippet(path, mediaURL string, uniqueID func(string) string) (out string, err error) {
+	hypeHTML, err := os.ReadFile(path)
+	if err != nil {
+		return "", &HypeFileError{Path: path, Err: err}
+	}
+	var snippet strings.Builder
+	var renamed []string
+	z := html.NewTokenizer(bytes.NewReader(hypeHTML))
+	depth := 0 // depth > 0 within a container div
+	inLoader := false
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				break
+			}
+			return "", &HypeFileError{Path: path, Err: z.Err()}
+		}
+		raw := string(z.Raw()) // Token may modify the raw text
+		t := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch {
+			case depth > 0:
+				if t.Data == "div" && tt == html.StartTagToken {
+					depth++
+				}
+			case t.Data == "div" && isHypeContainer(t.Attr):
+				depth = 1
+				for i, a := range t.Attr {
+					if a.Key == "id" && uniqueID != nil {
+						if id := uniqueID(a.Val); id != a.Val {
+							t.Attr[i].Val = id
+							renamed = append(renamed, a.Val)
+						}
+					}
+				}
+			case t.Data == "script" && isHypeLoader(t.Attr):
+				inLoader = tt == html.StartTagToken
+			default:
+				continue
+			}
+			extendURLs(t.Attr, mediaURL)
+			snippet.WriteString(t.String())
+		case html.EndTagToken:
+			switch {
+			case depth > 0:
+				if t.Data == "div" {
+					depth--
+				}
+			case inLoader && t.Data == "script":
+				inLoader = false
+			default:
+				continue
+			}
+			snippet.WriteString(t.String())
+			if depth == 0 && !inLoader {
+				snippet.WriteString("\n")
+			}
+		case html.TextToken:
+			if depth > 0 || inLoader {
+				snippet.WriteString(raw)
+			}
+		}
+	}
+
+	// Remove the indentation and the blank lines. A blank line
+	// would end the HTML block in Markdown.
+	for _, line := range strings.Split(snippet.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			out += line + "\n"
+		}
+	}
+	if out == "" {
+		return "", &HypeFileError{Path: path, Err: fmt.Errorf("no Hype animation container found")}
+	}
+	if len(renamed) > 0 {
+		return "", fmt.Errorf("Hype container id %q is used more than once in this post; export the animation under a different name", renamed[0])
+	}
+	return out + "\n", nil
+}
@@ -0,0 +1,35 @@
+package convert
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHypeMissingFile asserts that a HYPE tag without a Hype file gets
+// a message and the fallback, and that the fallback starts an HTML block.
+// The message contains a path and an error text of the operating system,
+// so it is not part of the golden files.
+func TestHypeMissingFile(t *testing.T) {
+	src := "/*\n+++\ntitle = \"Hype\"\n+++\nSummary\n<!--more-->\nIntro\nHYPE[A missing animation](missing.html)\n*/\n"
+	var warnings []error
+	c := newTestConverter("hype")
+	c.Warn = func(err error) { warnings = append(warnings, err) }
+	var out bytes.Buffer
+	if err := c.Convert(strings.NewReader(src), &out); err != nil {
+		t.Fatal(err)
+	}
+	var hypeErr *HypeFileError
+	if len(warnings) != 1 || !errors.As(warnings[0], &hypeErr) {
+		t.Fatalf("got warnings %v, want one *HypeFileError", warnings)
+	}
+	md := out.String()
+	if want := "no Hype file found at  " + filepath.Join("testdata", "hype", "missing.html"); !strings.Contains(md, want) {
+		t.Errorf("no message %q in the output:\n%s", want, md)
+	}
+	if !strings.Contains(md, problemMark+"\n\n"+hypeNoscript) {
+		t.Errorf("fallback does not start an HTML block:\n%s", md)
+	}
+}
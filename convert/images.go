@@ -0,0 +1,186 @@
+package convert
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	_ "image/gif" // for decoding GIF images
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// With ResponsiveImages, image tags become `<img>` elements with the width
+// and height of the image, so that the browser can reserve the space before
+// the image is loaded. With SrcsetWidths, smaller copies of the image are
+// written next to the original, and the `<img>` element lists them in
+// a `srcset` attribute. Only PNG, JPEG, and GIF images are supported, and
+// only the standard library is used for decoding, scaling, and encoding.
+
+const imageAttrPtrn = `!\[([^\]]*)\]\(\s*([^)"]*?)\s*(?:"([^"]*)")?\s*\)`
+
+var imageAttrs = regexp.MustCompile(imageAttrPtrn) // captures alt text, path, and title of an image tag
+
+// jpegQuality is the quality of downscaled JPEG images.
+const jpegQuality = 85
+
+// responsiveImages replaces the image tags in line by `<img>` elements.
+// Image tags whose file cannot be found in the media directory or cannot
+// be decoded are left unchanged. An error is returned only if a downscaled
+// copy cannot be written.
+func (c *Converter) responsiveImages(line, base string) (string, error) {
+	var err error
+	line = imageAttrs.ReplaceAllStringFunc(line, func(tag string) string {
+		m := imageAttrs.FindStringSubmatch(tag)
+		alt, path, title := m[1], m[2], m[3]
+		if !isLocal(path) || err != nil {
+			return tag
+		}
+		file := filepath.Join(c.mediaPath(base), filepath.FromSlash(path))
+		cfg, format, cerr := imageConfig(file)
+		if cerr != nil {
+			return tag
+		}
+		src := path
+		if !c.KeepImagePaths {
+			src = c.extendPath(path, base)
+		}
+		var srcset []string
+		for _, w := range c.SrcsetWidths {
+			if w <= 0 || w >= cfg.Width {
+				continue
+			}
+			variant := variantName(path, w, format)
+			if err = writeVariant(file, filepath.Join(c.mediaPath(base), filepath.FromSlash(variant)), w); err != nil {
+				return tag
+			}
+			vsrc := variant
+			if !c.KeepImagePaths {
+				vsrc = c.extendPath(variant, base)
+			}
+			srcset = append(srcset, fmt.Sprintf("%s %dw", vsrc, w))
+		}
+
+		out := fmt.Sprintf(`<img src="%s" alt="%s"`, html.EscapeString(src), html.EscapeString(alt))
+		if title != "" {
+			out += fmt.Sprintf(` title="%s"`, html.EscapeString(title))
+		}
+		out += fmt.Sprintf(` width="%d" height="%d"`, cfg.Width, cfg.Height)
+		if len(srcset) > 0 {
+			srcset = append(srcset, fmt.Sprintf("%s %dw", src, cfg.Width))
+			out += fmt.Sprintf(` srcset="%s" sizes="(max-width: %dpx) 100vw, %dpx"`,
+				html.EscapeString(strings.Join(srcset, ", ")), cfg.Width, cfg.Width)
+		}
+		return out + ">"
+	})
+	return line, err
+}
+
+// imageConfig returns the dimensions and the format of an image file.
+func imageConfig(path string) (image.Config, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, "", err
+	}
+	defer f.Close()
+	return image.DecodeConfig(f)
+}
+
+// variantName returns the path of the copy of the image `path`
+// that is scaled down to the given width, like `image-320w.png`.
+// JPEG images stay JPEG images, all others become PNG images.
+func variantName(path string, width int, format string) string {
+	ext := ".png"
+	if format == "jpeg" {
+		ext = filepath.Ext(path)
+	}
+	return Base(path) + "-" + strconv.Itoa(width) + "w" + ext
+}
+
+// writeVariant writes a copy of the image src, scaled down to the given
+// width, to dst. If dst is newer than src, it is left as it is.
+func writeVariant(src, dst string, width int) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("cannot stat image  %s: %w", src, err)
+	}
+	if dstInfo, err := os.Stat(dst); err == nil && !dstInfo.ModTime().Before(srcInfo.ModTime()) {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("cannot read image  %s: %w", src, err)
+	}
+	defer in.Close()
+	img, format, err := image.Decode(in)
+	if err != nil {
+		return fmt.Errorf("cannot decode image  %s: %w", src, err)
+	}
+	b := img.Bounds()
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	scaled := scale(img, width, height)
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("cannot write image  %s: %w", dst, err)
+	}
+	if format == "jpeg" {
+		err = jpeg.Encode(out, scaled, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(out, scaled)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot write image  %s: %w", dst, err)
+	}
+	return nil
+}
+
+// scale scales img down to the given size. Each pixel of the result
+// is the average of the pixels of img that it covers.
+func scale(img image.Image, width, height int) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := b.Min.Y + (y+1)*b.Dy()/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := b.Min.X + (x+1)*b.Dx()/width
+			if x1 == x0 {
+				x1++
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA() // premultiplied by alpha
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			if a == 0 {
+				continue // fully transparent
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r * 0xff / a),
+				G: uint8(g * 0xff / a),
+				B: uint8(bl * 0xff / a),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,47 @@
+package convert
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResponsiveImages(t *testing.T) {
+	out := t.TempDir()
+	dir := filepath.Join(out, "post")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "image.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewGray(image.Rect(0, 0, 400, 200))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	c := &Converter{OutDir: out, ResponsiveImages: true, SrcsetWidths: []int{100, 800}}
+	line, err := c.responsiveImages(`See ![An image](image.png "Title") and ![Missing](missing.png).`, "post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `See <img src="/post/image.png" alt="An image" title="Title" width="400" height="200"` +
+		` srcset="/post/image-100w.png 100w, /post/image.png 400w" sizes="(max-width: 400px) 100vw, 400px">` +
+		` and ![Missing](missing.png).`
+	if line != want {
+		t.Errorf("got\n%s\nwant\n%s", line, want)
+	}
+	cfg, _, err := imageConfig(filepath.Join(dir, "image-100w.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 100 || cfg.Height != 50 {
+		t.Errorf("variant is %dx%d, want 100x50", cfg.Width, cfg.Height)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "image-800w.png")); err == nil {
+		t.Error("variant wider than the image written")
+	}
+}
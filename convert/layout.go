@@ -0,0 +1,180 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// The layout of the generated Markdown is defined by a set of templates,
+// one for each section type:
+//
+// * "page": everything after the front matter. Gets .FrontMatter and .Content.
+// * "summary", "intro", "doc": the respective sections. Get .Text.
+// * "source": a run of consecutive comment/code pairs. Gets .Content.
+// * "pair": a comment/code pair. Gets .Text (the comment) and .Code.
+// * "plain": lines outside any section. Gets .Text.
+//
+// The templates use `[[` and `]]` as action delimiters, so that
+// Hugo shortcodes like `{{< div >}}` can be written as they are.
+const (
+	leftDelim  = "[["
+	rightDelim = "]]"
+)
+
+// defaultLayout generates a `gotohugo` namespace div and
+// nested divs for each section type, plus an announcement shortcode
+// after the summary. The names of the announcement shortcode and of
+// the code class come from the Converter.
+const defaultLayout = `[[define "page"]][[.FrontMatter]]{{< div gotohugo >}}
+[[.Content]]{{< divend >}} <!--gotohugo-->
+[[end]]
+
+[[define "summary"]]{{< div summary doc >}}
+[[.Text]]{{< divend >}} <!--summary doc-->
+[[end]]
+
+[[define "intro"]]
+<!--more-->
+
+{{< [[.Announcement]] >}}
+{{< div intro doc >}}
+[[.Text]]{{< divend >}} <!--intro doc-->
+[[end]]
+
+[[define "doc"]]{{< div doc >}}
+[[.Text]]{{< divend >}} <!--doc-->
+[[end]]
+
+[[define "source"]]{{< div source >}}
+[[.Content]]{{< divend >}} <!--source-->
+[[end]]
+
+[[/* The default code class, language-klipse-go, is used by the Klipse plugin. */]]
+[[define "pair"]]{{< div ccpair >}}
+{{< div comment >}}
+[[.Text]]{{< divend >}} <!--comment-->
+[[if .Code]]{{< div code [[.CodeClass]] >}}
+
+` + "```go" + `
+[[.Code]]` + "```" + `
+
+{{< divend >}} <!--code-->
+[[end]]{{< divend >}} <!--ccpair-->
+[[end]]
+
+[[define "plain"]][[.Text]][[end]]
+`
+
+// commonMarkLayout generates plain Markdown without any shortcodes.
+// Prose becomes paragraphs, and code goes into fenced go blocks.
+const commonMarkLayout = `[[define "page"]][[.FrontMatter]][[.Content]][[end]]
+
+[[define "summary"]][[with trimLines .Text]]
+[[.]]
+[[end]][[end]]
+
+[[define "intro"]][[with trimLines .Text]]
+[[.]]
+[[end]][[end]]
+
+[[define "doc"]][[with trimLines .Text]]
+[[.]]
+[[end]][[end]]
+
+[[define "source"]][[.Content]][[end]]
+
+[[define "pair"]][[with trimLines .Text]]
+[[.]]
+[[end]][[with trimLines .Code]]
+` + "```go" + `
+[[.]]
+` + "```" + `
+[[end]][[end]]
+
+[[define "plain"]][[with trimLines .Text]]
+[[.]]
+[[end]][[end]]
+`
+
+// layoutFuncs are available in all layout templates.
+var layoutFuncs = template.FuncMap{
+	// trimLines removes leading and trailing empty lines.
+	"trimLines": func(s string) string { return strings.Trim(s, "\n") },
+}
+
+// Layout is a set of templates that determine the markup around each section.
+type Layout struct {
+	t *template.Template
+}
+
+// LayoutData is passed to the layout templates.
+type LayoutData struct {
+	Name        string   // the base name of the post
+	Section     *Section // the section to render; nil for "page" and "source"
+	FrontMatter string   // the front matter, including delimiters; "page" only
+	Content     string   // the rendered inner sections; "page" and "source" only
+	Text        string   // the Markdown text of the section, or the comment of a comment/code pair
+	Code        string   // the code of a comment/code pair
+
+	Announcement string // the name of the shortcode after the summary
+	CodeClass    string // the class of the code div
+}
+
+// newLayout parses a built-in layout.
+func newLayout(text string) *Layout {
+	return &Layout{template.Must(template.New("layout").Delims(leftDelim, rightDelim).Funcs(layoutFuncs).Parse(text))}
+}
+
+// DefaultLayout returns the built-in layout that wraps sections into
+// `div` shortcodes.
+func DefaultLayout() *Layout {
+	return newLayout(defaultLayout)
+}
+
+// CommonMarkLayout returns the built-in layout for plain Markdown output
+// without any Hugo shortcodes.
+func CommonMarkLayout() *Layout {
+	return newLayout(commonMarkLayout)
+}
+
+// formatLayout returns the built-in layout of the output format f.
+func formatLayout(f Format) *Layout {
+	if f == CommonMark {
+		return CommonMarkLayout()
+	}
+	return DefaultLayout()
+}
+
+// LoadLayout reads template files that replace some or all of the
+// templates of the built-in layout of the output format f. The template
+// functions of the built-in layouts, like `trimLines`, are available.
+// A file can either contain `[[define "name"]]` blocks,
+// or its base name (without extension) is the name of the template it defines,
+// like `pair.tmpl`.
+func LoadLayout(f Format, files ...string) (*Layout, error) {
+	l := formatLayout(f)
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read layout file  %s: %w", file, err)
+		}
+		_, err = l.t.New(Base(filepath.Base(file))).Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse layout file  %s: %w", file, err)
+		}
+	}
+	return l, nil
+}
+
+// execute runs the named template and returns the output.
+func (l *Layout) execute(name string, data *LayoutData) (string, error) {
+	var out strings.Builder
+	err := l.t.ExecuteTemplate(&out, name, data)
+	if err != nil {
+		return "", fmt.Errorf("cannot render %s section: %w", name, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,47 @@
+package convert
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLoadLayout replaces the pair template of the CommonMark layout and
+// compares the output with testdata/basic.commonmark.md. The templates
+// that the layout directory does not define must come from the CommonMark
+// layout, not from the default layout with its shortcodes.
+func TestLoadLayout(t *testing.T) {
+	pair := filepath.Join(t.TempDir(), "pair.tmpl")
+	writeFiles(t, map[string]string{
+		pair: "[[with trimLines .Text]]\n> [[.]]\n[[end]][[with trimLines .Code]]\n```go\n[[.]]\n```\n[[end]]",
+	})
+	l, err := LoadLayout(CommonMark, pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(filepath.Join("testdata", "basic.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestConverter("basic")
+	c.Format, c.Layout = CommonMark, l
+	var out bytes.Buffer
+	if err := c.Convert(bytes.NewReader(src), &out); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "basic.commonmark.md")
+	if *update {
+		if err := os.WriteFile(golden, out.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != string(want) {
+		t.Errorf("output differs from %s:\n%s", golden, got)
+	}
+}
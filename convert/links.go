@@ -0,0 +1,87 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+)
+
+// Posts live side by side, each one in a directory of its own name:
+// `part1/part1.go`, `part2/part2.go`, and so on. A Markdown link from one
+// post to another, like `[see part 2](../part2/part2.go)`, works in the
+// source tree but not on the site. So links to `<name>/<name>.go` are
+// rewritten to Hugo's `ref` shortcode, `{{< ref "name" >}}`, or to the
+// relative path of the generated file for the other output formats.
+
+const (
+	postLinkPtrn = `(^|[^!\]])\[([^\]]*)\]\(\s*([^)\s"#]+\.go2?)(#[^)\s"]*)?\s*\)`
+	postRefPtrn  = `\]\(\{\{< ref "([^"#]+)(#[^"]*)?" >\}\}\)`
+)
+
+var (
+	postLink = regexp.MustCompile(postLinkPtrn) // matches a link to a Go file
+	postRef  = regexp.MustCompile(postRefPtrn)  // matches a rewritten link to a post
+)
+
+// postName returns the name of the post that link refers to,
+// or "" if link does not refer to a file `<name>/<name>.go`.
+func postName(link string) string {
+	if !isLocal(link) {
+		return ""
+	}
+	link = path.Clean(filepath.ToSlash(link))
+	name := Base(path.Base(link))
+	if path.Base(path.Dir(link)) != name {
+		return ""
+	}
+	return name
+}
+
+// postExists returns true if the post that link refers to exists, relative
+// to the source directory if known, or else at the output side.
+func (c *Converter) postExists(link, name string) bool {
+	if c.srcDir != "" {
+		return isFile(filepath.Join(c.srcDir, filepath.FromSlash(link)))
+	}
+	return isFile(filepath.Join(c.OutDir, c.PostDir, name))
+}
+
+// postURL returns the link target for the post name.
+func (c *Converter) postURL(name, anchor string) string {
+	if c.Format == Hugo {
+		return `{{< ref "` + name + anchor + `" >}}`
+	}
+	return "../" + name + "/" + outputNames[c.Format] + anchor
+}
+
+// rewritePostLinks rewrites the links to other posts in line.
+// If a post does not exist, the link is left as it is, and
+// the first missing post is returned as an error.
+func (c *Converter) rewritePostLinks(line string) (string, error) {
+	if isPreformatted(line) {
+		return line, nil
+	}
+	var err error
+	line = postLink.ReplaceAllStringFunc(line, func(link string) string {
+		m := postLink.FindStringSubmatch(link)
+		name := postName(m[3])
+		if name == "" {
+			return link
+		}
+		if !c.postExists(m[3], name) {
+			if err == nil {
+				err = fmt.Errorf("link to post  %s : post not found", m[3])
+			}
+			return link
+		}
+		return m[1] + "[" + m[2] + "](" + c.postURL(name, m[4]) + ")"
+	})
+	return line, err
+}
+
+// unrewritePostLinks turns `ref` shortcodes back into links to the source files
+// of sibling posts.
+func unrewritePostLinks(line string) string {
+	return postRef.ReplaceAllString(line, "](../$1/$1.go$2)")
+}
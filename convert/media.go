@@ -0,0 +1,60 @@
+package convert
+
+import (
+	"path"
+	"regexp"
+)
+
+const (
+	preformatPtrn = `\x60|^ {4,}|^\t\s*` // \x60 = backtick
+	imagePtrn     = `(!\[[^\]]+\]\( *)([^"\)]*?)(.*?\))`
+)
+
+var (
+	preformat = regexp.MustCompile(preformatPtrn) // matches preformatted text
+	imageTag  = regexp.MustCompile(imagePtrn)     // matches Markdown image tag
+)
+
+func isPreformatted(line string) bool {
+	return preformat.FindString(line) != ""
+}
+
+// extendPath takes a string that should contain a filename
+// and prepends `/<PublicMediaDir>/<basename>/` to it. The result is a URL,
+// so it uses forward slashes on all systems.
+func (c *Converter) extendPath(filename, basename string) string {
+	return "/" + path.Join(c.PublicMediaDir, basename, filename)
+}
+
+// extendImagePath receives a line of text and searches for an image
+// tag. If it finds one, it extends the image path to include
+// `/<PublicMediaDir>/<basename>/` and returns the modified line.
+// Otherwise, or if KeepImagePaths is set, it returns the unmodified line.
+func (c *Converter) extendImagePath(line, basename string) string {
+	if c.KeepImagePaths || isPreformatted(line) {
+		return line
+	}
+	return string(imageTag.ReplaceAllString(line, "$1"+c.extendPath("$2", basename)+"$3"))
+}
+
+/*
+imageTag should properly match the following image tags:
+
+`![Animation GIF](animation.gif)`
+
+![Animation GIF]( animation.gif )
+
+(Same but with spaces around the path:) ![Animation GIF with spaces]( animation.gif )
+
+`![Animation GIF with title](animation.gif "Title")` (With image title)
+
+![Animation GIF with title](animation.gif "Title")
+
+    ![Image with space in path](an image.png) (With a space in the path)
+
+![Image with space in path](an image.png)
+
+	Same but with title: ![With space and title](an image.png "Title")
+
+![With space and title](an image.png "Title")
+*/
@@ -0,0 +1,90 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A new post starts from a skeleton that follows the rules for
+// gotohugo-friendly source files: a `//go:generate` directive that
+// converts the file, front matter, a summary, the summary divider,
+// an intro, and a comment/code pair. `go generate` runs the directive in
+// the post directory, so the output directory must come from a
+// `gotohugo.toml` file or from `$HUGODIR`. Otherwise, ConvertFile refuses
+// to write the page into the media folder of the post.
+const postSkeleton = `//go:generate gotohugo convert $GOFILE
+/*
+
+Notes before the front matter, like license remarks, do not appear in the post.
+
+%s
+
+The summary of the post. Hugo shows it on the list page.
+
+<!--more-->
+
+The intro of the post.
+
+*/
+
+// The comment of a comment/code pair. The code follows right below.
+package main
+
+// main is where the code of the post starts.
+func main() {
+}
+`
+
+// frontMatterSkeletons are the front matter of a new post in
+// each syntax. The arguments are the title and the date.
+var frontMatterSkeletons = map[string]string{
+	"toml": `+++
+title = %s
+description = ""
+author = ""
+date = "%s"
+draft = true
+categories = []
+tags = []
++++`,
+	"yaml": `---
+title: %s
+description: ""
+author: ""
+date: "%s"
+draft: true
+categories: []
+tags: []
+---`,
+}
+
+// NewPost creates the directory `dir` for a new post, the media
+// subfolder `dir/<name>`, and a skeleton Go file `dir/<name>.go`, where
+// name is the base name of dir. The front matter has the given syntax,
+// "toml" or "yaml", and the given date, and it marks the post as a draft.
+// NewPost does not overwrite an existing Go file. It returns the path of
+// the Go file.
+func NewPost(dir, syntax string, date time.Time) (string, error) {
+	fm, ok := frontMatterSkeletons[strings.ToLower(syntax)]
+	if !ok {
+		return "", fmt.Errorf("unknown front matter syntax %q; use toml or yaml", syntax)
+	}
+	name := filepath.Base(filepath.Clean(dir))
+	if name == "." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid post directory  %s", dir)
+	}
+	file := filepath.Join(dir, name+".go")
+	if _, err := os.Stat(file); err == nil {
+		return "", fmt.Errorf("cannot create post  %s: file exists", file)
+	}
+	media := filepath.Join(dir, name)
+	if err := os.MkdirAll(media, 0755); err != nil {
+		return "", fmt.Errorf("cannot create media directory  %s: %w", media, err)
+	}
+	fm = fmt.Sprintf(fm, strconv.Quote(name), date.Format("2006-01-02"))
+	return file, writeFile(file, []byte(fmt.Sprintf(postSkeleton, fm)))
+}
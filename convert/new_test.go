@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPost(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, syntax := range []string{"toml", "yaml"} {
+		dir := filepath.Join(t.TempDir(), "mypost")
+		file, err := NewPost(dir, syntax, date)
+		if err != nil {
+			t.Fatalf("%s: %v", syntax, err)
+		}
+		if want := filepath.Join(dir, "mypost.go"); file != want {
+			t.Errorf("%s: got file %s, want %s", syntax, file, want)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "mypost")); err != nil {
+			t.Errorf("%s: no media directory: %v", syntax, err)
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diags := Check(src); len(diags) > 0 {
+			t.Errorf("%s: skeleton violates the rules: %v", syntax, diags)
+		}
+		if !strings.Contains(string(src), `"2024-03-01"`) {
+			t.Errorf("%s: skeleton has no date:\n%s", syntax, src)
+		}
+		if _, err := NewPost(dir, syntax, date); err == nil {
+			t.Errorf("%s: existing post was overwritten", syntax)
+		}
+	}
+}
+
+// TestNewPostGenerate converts a new post from within the post directory,
+// like its go:generate directive does.
+func TestNewPostGenerate(t *testing.T) {
+	root := t.TempDir()
+	file, err := NewPost(filepath.Join(root, "mypost"), "toml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Dir(file)); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// Without an output directory, the page would end up in the media folder.
+	c := &Converter{Warn: func(err error) { t.Error(err) }}
+	if err := c.ConvertFile("mypost.go"); err == nil {
+		t.Error("conversion into the media folder: want error, got nil")
+	}
+	if isFile(filepath.Join("mypost", "index.md")) {
+		t.Error("page written into the media folder")
+	}
+
+	writeFiles(t, map[string]string{
+		filepath.Join(root, ConfigFile):              "out = \"public\"\n",
+		filepath.Join(root, "public", "placeholder"): "",
+	})
+	cfg, err := LoadConfig(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err = cfg.Converter(); err != nil {
+		t.Fatal(err)
+	}
+	c.Warn = func(err error) { t.Error(err) }
+	if err := c.ConvertFile("mypost.go"); err != nil {
+		t.Fatal(err)
+	}
+	if !isFile(filepath.Join(root, "public", "mypost", "index.md")) {
+		t.Error("no page in the output directory")
+	}
+}
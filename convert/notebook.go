@@ -0,0 +1,141 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A commented Go tutorial maps onto the cells of a Jupyter notebook:
+// The summary, the intro, and doc sections become markdown cells, and each
+// comment/code pair becomes a markdown cell followed by a code cell.
+// The notebook uses the gophernotes kernel.
+
+// notebook is the nbformat 4 structure of a Jupyter notebook.
+type notebook struct {
+	Cells         []interface{}    `json:"cells"`
+	Metadata      notebookMetadata `json:"metadata"`
+	NBFormat      int              `json:"nbformat"`
+	NBFormatMinor int              `json:"nbformat_minor"`
+}
+
+type notebookMetadata struct {
+	KernelSpec   kernelSpec   `json:"kernelspec"`
+	LanguageInfo languageInfo `json:"language_info"`
+}
+
+type kernelSpec struct {
+	DisplayName string `json:"display_name"`
+	Language    string `json:"language"`
+	Name        string `json:"name"`
+}
+
+type languageInfo struct {
+	FileExtension string `json:"file_extension"`
+	MimeType      string `json:"mimetype"`
+	Name          string `json:"name"`
+}
+
+type markdownCell struct {
+	CellType string            `json:"cell_type"`
+	Metadata map[string]string `json:"metadata"`
+	Source   []string          `json:"source"`
+}
+
+type codeCell struct {
+	CellType       string            `json:"cell_type"`
+	ExecutionCount *int              `json:"execution_count"`
+	Metadata       map[string]string `json:"metadata"`
+	Outputs        []interface{}     `json:"outputs"`
+	Source         []string          `json:"source"`
+}
+
+// cellSource splits text into lines as nbformat expects them:
+// each line keeps its newline, except for the last one.
+// Leading and trailing empty lines are removed.
+func cellSource(text string) []string {
+	text = strings.Trim(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.SplitAfter(text, "\n")
+}
+
+// unmark removes the embed and problem markers from rendered prose.
+// Notebooks are not converted back, so the markers would only show up
+// as clutter in the markdown cells.
+func unmark(text string) string {
+	lines := strings.Split(text, "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		if line == embedEnd || strings.HasPrefix(line, embedStart) && strings.HasSuffix(line, " -->") {
+			continue
+		}
+		out = append(out, strings.TrimSuffix(line, problemMark))
+	}
+	return strings.Join(out, "\n")
+}
+
+// notebookCells turns the sections of d into notebook cells.
+// The front matter is not part of the notebook.
+func (c *Converter) notebookCells(d *Document, base string) ([]interface{}, error) {
+	cells := []interface{}{}
+	// markdown and code add a cell unless the text is empty.
+	markdown := func(text string) {
+		if src := cellSource(text); src != nil {
+			cells = append(cells, markdownCell{CellType: "markdown", Metadata: map[string]string{}, Source: src})
+		}
+	}
+	code := func(text string) {
+		if src := cellSource(text); src != nil {
+			cells = append(cells, codeCell{CellType: "code", Metadata: map[string]string{}, Outputs: []interface{}{}, Source: src})
+		}
+	}
+	for _, s := range d.Sections {
+		switch s.Kind {
+		case Summary:
+			markdown(verbatim(s.Text))
+		case Intro, Doc, CommentCodePair:
+			text, err := c.prose(s, base)
+			if err != nil {
+				return nil, err
+			}
+			markdown(unmark(text))
+			code(verbatim(s.Code))
+		case Plain:
+			code(verbatim(s.Text))
+		}
+	}
+	return cells, nil
+}
+
+// WriteNotebook writes d as a Jupyter notebook for the gophernotes kernel to w.
+// Jupyter does not know Hugo shortcodes, so the prose is rendered as
+// plain Markdown whatever the format of the post.
+func (c *Converter) WriteNotebook(w io.Writer, d *Document) error {
+	rc := *c
+	rc.ids = map[string]int{}
+	rc.Format = CommonMark
+	c = &rc
+	cells, err := c.notebookCells(d, c.Name)
+	if err != nil {
+		return err
+	}
+	nb := notebook{
+		Cells: cells,
+		Metadata: notebookMetadata{
+			KernelSpec:   kernelSpec{DisplayName: "Go", Language: "go", Name: "gophernotes"},
+			LanguageInfo: languageInfo{FileExtension: ".go", MimeType: "text/x-go", Name: "go"},
+		},
+		NBFormat:      4,
+		NBFormatMinor: 4,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(nb); err != nil {
+		return fmt.Errorf("cannot write notebook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteNotebook(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("testdata", "basic.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := newTestConverter("basic").WriteNotebook(&out, Parse(src)); err != nil {
+		t.Fatal(err)
+	}
+	var nb struct {
+		Cells []struct {
+			CellType       string         `json:"cell_type"`
+			Source         []string       `json:"source"`
+			Outputs        *[]interface{} `json:"outputs"`
+			ExecutionCount *int           `json:"execution_count"`
+		} `json:"cells"`
+		Metadata struct {
+			KernelSpec struct {
+				Name string `json:"name"`
+			} `json:"kernelspec"`
+		} `json:"metadata"`
+		NBFormat int `json:"nbformat"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &nb); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if nb.NBFormat != 4 || nb.Metadata.KernelSpec.Name != "gophernotes" {
+		t.Errorf("got nbformat %d and kernel %q", nb.NBFormat, nb.Metadata.KernelSpec.Name)
+	}
+
+	want := []struct {
+		cellType string
+		source   []string
+	}{
+		{"markdown", []string{"The summary."}},
+		{"markdown", []string{"The intro."}},
+		{"markdown", []string{"## Imports"}},
+		{"code", []string{"package main\n", "\n", "import \"fmt\""}},
+		{"markdown", []string{"A second pair directly after code."}},
+		{"code", []string{"func hello() {\n", "\tfmt.Println(\"hello\")\n", "}"}},
+		{"markdown", []string{"### A doc section\n", "\n", "Rendered as a single column."}},
+		{"markdown", []string{"Code at the end of the file."}},
+		{"code", []string{"func main() {\n", "\thello()\n", "}"}},
+	}
+	if len(nb.Cells) != len(want) {
+		t.Fatalf("got %d cells, want %d:\n%s", len(nb.Cells), len(want), out.String())
+	}
+	for i, cell := range nb.Cells {
+		if cell.CellType != want[i].cellType || !reflect.DeepEqual(cell.Source, want[i].source) {
+			t.Errorf("cell %d: got %s %q, want %s %q", i, cell.CellType, cell.Source, want[i].cellType, want[i].source)
+		}
+		// Code cells need outputs, and they have not run yet.
+		if cell.CellType == "code" && (cell.Outputs == nil || cell.ExecutionCount != nil) {
+			t.Errorf("cell %d: got outputs %v and execution count %v", i, cell.Outputs, cell.ExecutionCount)
+		}
+	}
+}
+
+// TestNotebookProse asserts that the markdown cells contain plain links
+// and no markers, whatever the format of the post.
+func TestNotebookProse(t *testing.T) {
+	const src = "/*\n+++\n+++\nSummary\n<!--more-->\n[Other](../other/other.go)\n\nVIDEO[A video](demo.mp4)\n\nHYPE[Missing](missing.html)\n*/\n"
+	var out bytes.Buffer
+	if err := newTestConverter("notebook").WriteNotebook(&out, Parse([]byte(src))); err != nil {
+		t.Fatal(err)
+	}
+	var nb struct {
+		Cells []struct {
+			Source []string `json:"source"`
+		} `json:"cells"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &nb); err != nil {
+		t.Fatal(err)
+	}
+	if len(nb.Cells) != 2 {
+		t.Fatalf("got %d cells, want 2:\n%s", len(nb.Cells), out.String())
+	}
+	intro := strings.Join(nb.Cells[1].Source, "")
+	for _, want := range []string{"[Other](../other/index.md)", "<video", "missing.html"} {
+		if !strings.Contains(intro, want) {
+			t.Errorf("%s missing from the intro:\n%s", want, intro)
+		}
+	}
+	for _, marker := range []string{"{{<", "<!--"} {
+		if strings.Contains(intro, marker) {
+			t.Errorf("%s in the intro:\n%s", marker, intro)
+		}
+	}
+}
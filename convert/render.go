@@ -0,0 +1,181 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+)
+
+// Render writes the Markdown representation of d to w,
+// or an HTML page if the output format is HTML.
+// Problems like a missing Hype file are returned as *Error in strict mode.
+// Otherwise, problems in the prose are embedded into the output. Missing
+// front matter and front matter syntax errors are only passed to Warn, as
+// a message would break the front matter.
+func (c *Converter) Render(w io.Writer, d *Document) error {
+	if len(d.Sections) == 0 {
+		if _, err := c.problem(1, ErrMissingFrontMatter); err != nil {
+			return err
+		}
+	} else if _, err := decodeFrontMatter(d.Sections[0]); err != nil {
+		e := err.(*Error)
+		if _, err := c.problem(e.Line, e.Err); err != nil {
+			return err
+		}
+	}
+	// Each document starts with a fresh set of ids for embed tags.
+	rc := *c
+	rc.ids = map[string]int{}
+	c = &rc
+	render := c.render
+	if c.Format == HTML {
+		render = c.renderHTML
+	}
+	out, err := render(d, c.Name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	if err != nil {
+		return fmt.Errorf("cannot write %s output: %w", c.Format, err)
+	}
+	return nil
+}
+
+// prose processes the Markdown text of the intro, of comments, or of doc
+// sections. Image paths are extended, or image tags become `<img>` elements
+// if ResponsiveImages is set. Links to other posts are rewritten. Embed tags like Hype tags are replaced by
+// the HTML from their Embedder, between embed markers.
+func (c *Converter) prose(s *Section, base string) (out string, err error) {
+	for i, line := range s.Text {
+		if c.ResponsiveImages && !isPreformatted(line) {
+			line, err = c.responsiveImages(line, base)
+			if err != nil {
+				msg, err := c.problem(s.Start+i, err)
+				if err != nil {
+					return "", err
+				}
+				out += msg + "\n"
+			}
+		}
+		line, err = c.rewritePostLinks(line)
+		if err != nil {
+			msg, err := c.problem(s.Start+i, err)
+			if err != nil {
+				return "", err
+			}
+			out += msg + "\n"
+		}
+		line = c.extendImagePath(line, base)
+		snippet, found, err := c.replaceEmbedTag(line, base)
+		if err != nil {
+			msg, err := c.problem(s.Start+i, err)
+			if err != nil {
+				return "", err
+			}
+			snippet = msg + "\n\n" + snippet // a blank line lets the fallback HTML start an HTML block
+		}
+		if found {
+			out += markEmbed(s.Text[i], snippet)
+			continue
+		}
+		out += line + "\n"
+	}
+	return out, nil
+}
+
+// verbatim joins lines without any processing.
+func verbatim(lines []string) (out string) {
+	for _, line := range lines {
+		out += line + "\n"
+	}
+	return out
+}
+
+// layout returns the layout to use for rendering.
+// A custom layout takes precedence over the layout of the output format.
+func (c *Converter) layout() *Layout {
+	if c.Layout != nil {
+		return c.Layout
+	}
+	return formatLayout(c.Format)
+}
+
+// layoutData adds the settings of c to data.
+func (c *Converter) layoutData(base string, data *LayoutData) *LayoutData {
+	data.Name = base
+	data.Announcement = c.Announcement
+	if data.Announcement == "" {
+		data.Announcement = DefaultAnnouncement
+	}
+	data.CodeClass = c.CodeClass
+	if data.CodeClass == "" {
+		data.CodeClass = DefaultCodeClass
+	}
+	return data
+}
+
+// render turns the sections of d into Markdown, using the layout templates.
+// Consecutive comment/code pairs are grouped and rendered through the "source" template,
+// and everything after the front matter goes through the "page" template.
+func (c *Converter) render(d *Document, base string) (string, error) {
+	l := c.layout()
+	var frontmatter, content, source string
+
+	// flush renders the pending comment/code pairs as a source block.
+	flush := func() error {
+		if source == "" {
+			return nil
+		}
+		out, err := l.execute("source", c.layoutData(base, &LayoutData{Content: source}))
+		content += out
+		source = ""
+		return err
+	}
+
+	for _, s := range d.Sections {
+		data := c.layoutData(base, &LayoutData{Section: s})
+		var name string
+		var err error
+		switch s.Kind {
+		case FrontMatter:
+			frontmatter += verbatim(s.Text)
+			continue
+		case Summary:
+			name = "summary"
+			data.Text = verbatim(s.Text)
+		case Intro:
+			name = "intro"
+			data.Text, err = c.prose(s, base)
+		case Doc:
+			name = "doc"
+			data.Text, err = c.prose(s, base)
+		case CommentCodePair:
+			name = "pair"
+			data.Text, err = c.prose(s, base)
+			data.Code = verbatim(s.Code)
+		case Plain:
+			name = "plain"
+			data.Text = verbatim(s.Text)
+		}
+		if err != nil {
+			return "", err
+		}
+		out, err := l.execute(name, data)
+		if err != nil {
+			return "", err
+		}
+		if s.Kind == CommentCodePair {
+			source += out
+			continue
+		}
+		// Any section other than a comment/code pair ends a source block.
+		if err := flush(); err != nil {
+			return "", err
+		}
+		content += out
+	}
+	if err := flush(); err != nil {
+		return "", err
+	}
+	return l.execute("page", c.layoutData(base, &LayoutData{FrontMatter: frontmatter, Content: content}))
+}
@@ -0,0 +1,346 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Reverse conversion reads a Markdown file generated with the default
+// layout and reconstructs the commented Go source from it, so that edits
+// made to the Markdown file can flow back into the source.
+// The reconstructed source contains the front matter, summary and intro
+// in the first `/* */` comment, doc sections as `/* */` comments,
+// and comment/code pairs as `//` comments followed by code.
+// Anything that preceded the front matter in the original source is lost.
+
+const (
+	divPtrn       = `^\{\{< div (.+?) >\}\}\s*$`
+	divEndPtrn    = `^\{\{< divend >\}\}`
+	hypeSrcPtrn   = `src="[^"]*?([^/"]+)\.hyperesources/`
+	hypeContainer = `_hype_container`
+	noHype        = `<noscript class="nohype">`
+)
+
+// divKinds are the kinds of the sections that the content of a div belongs to.
+var divKinds = map[string]SectionKind{
+	"summary": Summary,
+	"intro":   Intro,
+	"doc":     Doc,
+	"comment": CommentCodePair,
+	"code":    CommentCodePair,
+}
+
+var (
+	divStart = regexp.MustCompile(divPtrn)     // matches a div shortcode
+	divStop  = regexp.MustCompile(divEndPtrn)  // matches a divend shortcode
+	hypeSrc  = regexp.MustCompile(hypeSrcPtrn) // matches the resources path in a Hype snippet
+)
+
+// unextendImagePath removes the path that extendImagePath has prepended
+// to the image tags in line.
+func (c *Converter) unextendImagePath(line, basename string) string {
+	if c.KeepImagePaths || isPreformatted(line) {
+		return line
+	}
+	prefix := c.extendPath("", basename) + "/"
+	return imageTag.ReplaceAllStringFunc(line, func(tag string) string {
+		m := imageTag.FindStringSubmatch(tag)
+		return m[1] + strings.TrimPrefix(m[2]+m[3], prefix)
+	})
+}
+
+// unprose reverts the processing of prose: Image paths are shortened
+// again, links to other posts point to their source again, messages about
+// problems are dropped, and the HTML between embed markers is turned back
+// into the embed tag. Hype snippets
+// from before the embed markers are turned back into HYPE tags, too. The
+// description of a HYPE tag is not part of the snippet, so the animation
+// name is used instead.
+func (c *Converter) unprose(lines []string, basename string) (out []string) {
+	inHype := false
+	inEmbed := false
+	hypeName := ""
+	for _, line := range lines {
+		if inEmbed {
+			inEmbed = line != embedEnd
+			continue
+		}
+		if strings.HasSuffix(line, problemMark) {
+			continue // a message that the conversion added
+		}
+		if strings.HasPrefix(line, embedStart) && strings.HasSuffix(line, " -->") {
+			out = append(out, strings.TrimSuffix(strings.TrimPrefix(line, embedStart), " -->"))
+			inEmbed = true
+			continue
+		}
+		if inHype {
+			if m := hypeSrc.FindStringSubmatch(line); m != nil {
+				hypeName = m[1]
+			}
+			if strings.Contains(line, noHype) {
+				out = append(out, "HYPE["+hypeName+"]("+hypeName+".html)")
+				inHype = false
+			}
+			continue
+		}
+		if strings.Contains(line, hypeContainer) {
+			inHype = true
+			hypeName = ""
+			if m := hypeSrc.FindStringSubmatch(line); m != nil {
+				hypeName = m[1]
+			}
+			continue
+		}
+		out = append(out, unrewritePostLinks(c.unextendImagePath(line, basename)))
+	}
+	return out
+}
+
+// unfence removes the blank lines and code fences that the default
+// layout puts around the code of a comment/code pair.
+func unfence(lines []string) []string {
+	start, end := 0, len(lines)
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			start = i + 1
+			break
+		}
+	}
+	for i := len(lines) - 1; i >= start; i-- {
+		if strings.HasPrefix(lines[i], "```") {
+			end = i
+			break
+		}
+	}
+	return lines[start:end]
+}
+
+// isBlank returns true if all lines are empty or contain only whitespace.
+func isBlank(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseMarkdown reads a Markdown document generated with the default layout
+// and reconstructs the Document from the div shortcodes.
+// Line numbers in the sections refer to the Markdown document.
+func (c *Converter) ParseMarkdown(md []byte) (*Document, error) {
+	d := &Document{}
+	var sec *Section
+	var stack []string // the names of the open divs
+	var code []string  // the content of a code div, including fences
+	announcement := "{{< " + c.layoutData(c.Name, &LayoutData{}).Announcement + " >}}"
+
+	// inSection returns an error unless the content of the div name
+	// at line n belongs to the current section.
+	inSection := func(name string, n int) error {
+		if sec == nil || sec.Kind != divKinds[name] {
+			return fmt.Errorf("line %d: div %q outside a section", n, name)
+		}
+		return nil
+	}
+
+	// open starts a new section at line n.
+	open := func(kind SectionKind, n int) {
+		sec = &Section{Kind: kind, Start: n, End: n}
+		d.Sections = append(d.Sections, sec)
+	}
+
+	in := strings.Replace(string(md), "\r", "", -1)
+	lines := strings.Split(strings.TrimSuffix(in, "\n"), "\n")
+	for i, line := range lines {
+		n := i + 1
+
+		if m := divStart.FindStringSubmatch(line); m != nil {
+			fields := strings.Fields(m[1])
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: div without a name", n)
+			}
+			name := fields[0]
+			stack = append(stack, name)
+			switch name {
+			case "gotohugo", "source", "comment":
+			case "summary":
+				open(Summary, n)
+			case "intro":
+				open(Intro, n)
+			case "doc":
+				open(Doc, n)
+			case "ccpair":
+				open(CommentCodePair, n)
+			case "code":
+				code = nil
+			default:
+				return nil, fmt.Errorf("line %d: unknown div %q", n, m[1])
+			}
+			continue
+		}
+
+		if divStop.MatchString(line) {
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: divend without div", n)
+			}
+			name := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if name == "code" {
+				if err := inSection(name, n); err != nil {
+					return nil, err
+				}
+				sec.Code = unfence(code)
+			}
+			if sec != nil {
+				sec.End = n
+			}
+			continue
+		}
+
+		// Content outside of any div is front matter.
+		if len(stack) == 0 {
+			if len(d.Sections) == 0 {
+				open(FrontMatter, n)
+			}
+			if sec.Kind == FrontMatter {
+				sec.Text = append(sec.Text, line)
+				sec.End = n
+			}
+			continue
+		}
+
+		switch name := stack[len(stack)-1]; name {
+		case "summary", "intro", "doc", "comment":
+			if err := inSection(name, n); err != nil {
+				return nil, err
+			}
+			sec.Text = append(sec.Text, line)
+		case "code":
+			if err := inSection(name, n); err != nil {
+				return nil, err
+			}
+			code = append(code, line)
+		default:
+			// Lines directly within the gotohugo or source div.
+			// The summary divider, the announcement, and the empty lines
+			// around them are generated by the layout.
+			if isSummaryDivider(line) || strings.TrimSpace(line) == announcement {
+				continue
+			}
+			if sec != nil && sec.Kind == Summary && strings.TrimSpace(line) == "" {
+				continue
+			}
+			if sec == nil || sec.Kind != Plain {
+				open(Plain, n)
+			}
+			sec.Text = append(sec.Text, line)
+			sec.End = n
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("line %d: div %q is not closed", len(lines), stack[len(stack)-1])
+	}
+
+	// Revert the prose processing.
+	for _, s := range d.Sections {
+		switch s.Kind {
+		case Intro, Doc, CommentCodePair:
+			s.Text = c.unprose(s.Text, c.Name)
+		}
+	}
+	return d, nil
+}
+
+// WriteSource writes d as commented Go source to w.
+// Front matter, summary, and intro go into the first `/* */` comment.
+// The empty lines between sections come from the Plain sections of d.
+func WriteSource(w io.Writer, d *Document) error {
+	var out strings.Builder
+	inIntro := false // true while writing the first multiline comment
+
+	// closeIntro ends the first multiline comment.
+	closeIntro := func() {
+		if inIntro {
+			out.WriteString("*/\n")
+			inIntro = false
+		}
+	}
+
+	for _, s := range d.Sections {
+		switch s.Kind {
+		case FrontMatter:
+			out.WriteString("/*\n")
+			out.WriteString(verbatim(s.Text))
+			inIntro = true
+		case Summary:
+			if !inIntro {
+				out.WriteString("/*\n")
+				inIntro = true
+			}
+			out.WriteString(verbatim(s.Text))
+		case Intro:
+			if !inIntro {
+				out.WriteString("/*\n")
+				inIntro = true
+			}
+			out.WriteString("<!--more-->\n")
+			out.WriteString(verbatim(s.Text))
+			closeIntro()
+		case Doc:
+			closeIntro()
+			text := s.Text
+			if len(text) > 0 && text[0] != "" {
+				out.WriteString("/* " + text[0] + "\n")
+			} else {
+				out.WriteString("/*\n")
+			}
+			if len(text) > 0 {
+				out.WriteString(verbatim(text[1:]))
+			}
+			out.WriteString("*/\n")
+		case CommentCodePair:
+			closeIntro()
+			for _, line := range s.Text {
+				if line == "" {
+					out.WriteString("//\n")
+					continue
+				}
+				out.WriteString("// " + line + "\n")
+			}
+			out.WriteString(verbatim(s.Code))
+		case Plain:
+			closeIntro()
+			out.WriteString(verbatim(s.Text))
+		}
+	}
+	closeIntro()
+
+	// The last line of a source that ends with a newline is empty, and
+	// it ends up in the last section. It stands for the final newline.
+	src := out.String()
+	if strings.HasSuffix(src, "\n\n") {
+		src = src[:len(src)-1]
+	}
+	_, err := io.WriteString(w, src)
+	if err != nil {
+		return fmt.Errorf("cannot write Go source: %w", err)
+	}
+	return nil
+}
+
+// Reverse reads a Markdown document generated with the default layout
+// from r and writes the reconstructed Go source to w.
+func (c *Converter) Reverse(r io.Reader, w io.Writer) error {
+	md, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("cannot read Markdown: %w", err)
+	}
+	d, err := c.ParseMarkdown(md)
+	if err != nil {
+		return fmt.Errorf("cannot parse Markdown: %w", err)
+	}
+	return WriteSource(w, d)
+}
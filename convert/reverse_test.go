@@ -0,0 +1,96 @@
+package convert
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReverseEmbeds asserts that reverse turns the HTML of embed tags
+// back into the tags of the source.
+func TestReverseEmbeds(t *testing.T) {
+	for _, name := range []string{"embeds", "hype"} {
+		src, err := os.ReadFile(filepath.Join("testdata", name+".go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		md, err := os.ReadFile(filepath.Join("testdata", name+".md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := newTestConverter(name).Reverse(bytes.NewReader(md), &out); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got := out.String()
+		for _, line := range strings.Split(string(src), "\n") {
+			if findEmbedTag(line, DefaultEmbeds()) != nil && !strings.Contains(got, line+"\n") {
+				t.Errorf("%s: tag %s not restored:\n%s", name, line, got)
+			}
+		}
+		for _, html := range []string{"<video", "<script", "<iframe", "<svg", "<div", "<noscript", embedStart, "Hype file"} {
+			if strings.Contains(got, html) {
+				t.Errorf("%s: %s in the reversed source:\n%s", name, html, got)
+			}
+		}
+	}
+}
+
+// TestReverseRoundTrip converts each testdata/<name>.go file, reverses the
+// Markdown, and converts the result again. Both conversions must give the
+// same Markdown, so that no empty lines or messages pile up in the source.
+// The reversed source ends with a newline, so the test adds the newline
+// to sources without one.
+func TestReverseRoundTrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		name := Base(filepath.Base(file))
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasSuffix(src, []byte("\n")) {
+				src = append(src, '\n')
+			}
+			c := newTestConverter(name)
+			var md, reversed, again bytes.Buffer
+			if err := c.Convert(bytes.NewReader(src), &md); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Reverse(bytes.NewReader(md.Bytes()), &reversed); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Convert(bytes.NewReader(reversed.Bytes()), &again); err != nil {
+				t.Fatal(err)
+			}
+			if md.String() != again.String() {
+				t.Errorf("Markdown changed after the round trip:\n%s\nreversed source:\n%s", again.String(), reversed.String())
+			}
+		})
+	}
+}
+
+// TestReverseMalformed asserts that reverse reports hand-edited Markdown
+// with broken divs, like divs outside their sections, instead of panicking.
+func TestReverseMalformed(t *testing.T) {
+	tests := []struct{ name, md, want string }{
+		{"code without pair", "{{< div code x >}}\n{{< divend >}}\n", `line 2: div "code" outside a section`},
+		{"code line without pair", "{{< div code x >}}\nvar a = 1\n{{< divend >}}\n", `line 2: div "code" outside a section`},
+		{"comment without pair", "{{< div comment >}}\nhi\n{{< divend >}}\n", `line 2: div "comment" outside a section`},
+		{"comment in doc", "{{< div doc >}}\n{{< div comment >}}\nhi\n{{< divend >}}\n{{< divend >}}\n", `line 3: div "comment" outside a section`},
+		{"div without a name", "{{< div \t >}}\n{{< divend >}}\n", "line 1: div without a name"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := newTestConverter("malformed").Reverse(strings.NewReader(test.md), &out)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want %s", test.name, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package convert
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// Deciding whether a line is a comment or code cannot be done reliably
+// with regular expressions. A `//` can be part of a string literal or a URL
+// inside a raw string, and a `/* ... */` comment can open and close after
+// some code on the same line. So instead of looking at each line in isolation,
+// gotohugo runs the source through `go/scanner` and derives the kind of each
+// line from the positions of the tokens.
+
+// lineKind describes what a source line consists of.
+type lineKind int
+
+const (
+	codeLine    lineKind = iota // code, possibly with trailing comments, or an empty line
+	lineComment                 // a `//` comment and nothing else
+	blockStart                  // the first line of a `/* */` comment that is not preceded by code
+	blockInner                  // a line within a `/* */` comment
+	blockEnd                    // the last line of a `/* */` comment that is not followed by code
+	blockSingle                 // a `/* */` comment that starts and ends on the same line, without code
+)
+
+// srcLine is a line of the source file, classified by scanLines.
+type srcLine struct {
+	kind lineKind
+	raw  string // the line as it appears in the source
+	text string // the line with comment delimiters stripped
+	col  int    // the column where a comment starts, starting at 1
+}
+
+// stripSpace removes a single space or tab that follows a comment delimiter.
+func stripSpace(s string) string {
+	if len(s) > 0 && (s[0] == ' ' || s[0] == '\t') {
+		return s[1:]
+	}
+	return s
+}
+
+// scanLines tokenizes `src` and returns one classified srcLine per line.
+// The source does not need to be valid Go; scanner errors are ignored, as
+// gotohugo only cares about where comments start and end.
+func scanLines(src string) []srcLine {
+	raw := strings.Split(src, "\n")
+	lines := make([]srcLine, len(raw))
+	for i, r := range raw {
+		lines[i] = srcLine{kind: codeLine, raw: r, text: r}
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), func(token.Position, string) {}, scanner.ScanComments)
+
+	// lineOf returns the zero-based line index of the given byte offset.
+	lineOf := func(offset int) int {
+		return file.Line(file.Pos(offset)) - 1
+	}
+
+	type comment struct {
+		start, end int // byte offsets
+		col        int
+		text       string
+	}
+	var comments []comment
+	hasCode := make([]bool, len(raw))
+
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// Skip semicolons that the scanner inserts automatically at line ends.
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		start := file.Offset(pos)
+		if tok == token.COMMENT {
+			comments = append(comments, comment{start, start + len(lit), file.Position(pos).Column, lit})
+			continue
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		// Literals like raw strings can span multiple lines.
+		for l := lineOf(start); l <= lineOf(start+len(lit)-1); l++ {
+			hasCode[l] = true
+		}
+	}
+
+	for _, c := range comments {
+		first, last := lineOf(c.start), lineOf(c.end-1)
+		if strings.HasPrefix(c.text, "//") {
+			if !hasCode[first] {
+				lines[first].kind = lineComment
+				lines[first].text = stripSpace(c.text[2:])
+				lines[first].col = c.col
+			}
+			continue
+		}
+		// A block comment that shares a line with code is part of the code.
+		if hasCode[first] || hasCode[last] {
+			for l := first; l <= last; l++ {
+				hasCode[l] = true
+			}
+			continue
+		}
+		body := strings.TrimSuffix(c.text[2:], "*/")
+		lines[first].col = c.col
+		if first == last {
+			lines[first].kind = blockSingle
+			lines[first].text = strings.TrimSpace(body)
+			continue
+		}
+		lines[first].kind = blockStart
+		lines[first].text = stripSpace(strings.TrimRight(body[:strings.Index(body, "\n")], " \t"))
+		for l := first + 1; l < last; l++ {
+			lines[l].kind = blockInner
+		}
+		lines[last].kind = blockEnd
+		lines[last].text = strings.TrimRight(body[strings.LastIndex(body, "\n")+1:], " \t")
+	}
+	return lines
+}
@@ -0,0 +1,244 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// The SVG tag inlines the markup of an SVG file, so that the page's CSS
+// can style the image and its text can be found by search engines.
+// As the markup becomes part of the page, it is sanitized: scripts, event
+// handlers, and `javascript:` URLs are removed. Element ids are prefixed
+// with the names of the post and the SVG file, so that they do not collide
+// with ids of the page or of other SVG images.
+
+const svgRefPtrn = `url\(\s*['"]?#([^'")\s]+)['"]?\s*\)|#([A-Za-z_][\w.-]*)`
+
+var svgRef = regexp.MustCompile(svgRefPtrn) // matches `url(#id)` references, and `#id` selectors in style sheets
+
+// textEscaper escapes the text content of elements.
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// unsafeSVGElements are removed along with their content. The keys are
+// lowercase, as HTML parsers ignore the case of tag names.
+var unsafeSVGElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+}
+
+// svgIDLists are attributes that contain a space-separated list of ids.
+var svgIDLists = map[string]bool{
+	"aria-labelledby":  true,
+	"aria-describedby": true,
+}
+
+// embedSVG inlines the SVG file of the tag. If the file cannot be read,
+// it falls back to an `<img>` element.
+func embedSVG(tag *EmbedTag) (string, error) {
+	fallback := fmt.Sprintf(`<img src="%s" alt="%s">`+"\n", html.EscapeString(tag.URL), html.EscapeString(tag.Description))
+	if tag.File == "" {
+		return fallback, fmt.Errorf("SVG tag needs a local file, not  %s", tag.Path)
+	}
+	src, err := os.ReadFile(tag.File)
+	if err != nil {
+		return fallback, fmt.Errorf("cannot read SVG file  %s: %w", tag.File, err)
+	}
+	out, err := inlineSVG(src, idPrefix(tag.Post, Base(tag.Path)), tag.Description)
+	if err != nil {
+		return fallback, fmt.Errorf("cannot inline SVG file  %s: %w", tag.File, err)
+	}
+	return out, nil
+}
+
+// idPrefix turns the names into a prefix for element ids.
+func idPrefix(names ...string) string {
+	var prefix strings.Builder
+	for _, name := range names {
+		for _, r := range name {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+				prefix.WriteRune(r)
+			} else {
+				prefix.WriteRune('_')
+			}
+		}
+		prefix.WriteRune('-')
+	}
+	return prefix.String()
+}
+
+// newSVGDecoder returns a decoder that accepts the HTML entities
+// and the sloppy markup that some drawing tools produce.
+func newSVGDecoder(src []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(src))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+// attrName returns the name of an attribute as written in the source,
+// including the namespace prefix.
+func attrName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+// inlineSVG returns the sanitized markup of the SVG document src, with
+// all ids prefixed by prefix, and with label as the aria-label of the image,
+// in place of the aria-label or aria-labelledby of the root element.
+// Blank lines are removed, as they would end the HTML block in Markdown.
+func inlineSVG(src []byte, prefix, label string) (string, error) {
+	// Collect the ids first, because references can precede the element.
+	ids := map[string]bool{}
+	d := newSVGDecoder(src)
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			for _, a := range start.Attr {
+				if attrName(a.Name) == "id" {
+					ids[a.Value] = true
+				}
+			}
+		}
+	}
+
+	// rename prefixes the `url(#id)` references in s, and with
+	// selectors set, also the `#id` selectors of a style sheet.
+	rename := func(s string, selectors bool) string {
+		return svgRef.ReplaceAllStringFunc(s, func(ref string) string {
+			m := svgRef.FindStringSubmatch(ref)
+			if ids[m[1]] {
+				return "url(#" + prefix + m[1] + ")"
+			}
+			if selectors && ids[m[2]] {
+				return "#" + prefix + m[2]
+			}
+			return ref
+		})
+	}
+
+	var out strings.Builder
+	var pending *xml.StartElement // not written yet, to write empty elements as `<name/>`
+	flush := func(empty bool) {
+		if pending == nil {
+			return
+		}
+		out.WriteString("<" + attrName(pending.Name))
+		for _, a := range pending.Attr {
+			out.WriteString(" " + attrName(a.Name) + `="` + html.EscapeString(a.Value) + `"`)
+		}
+		if empty {
+			out.WriteString("/>")
+		} else {
+			out.WriteString(">")
+		}
+		pending = nil
+	}
+
+	d = newSVGDecoder(src)
+	var elements []string // the names of the open elements
+	skip := 0             // skip > 0 within an unsafe element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(elements) == 0 && t.Name.Local != "svg" {
+				return "", fmt.Errorf("root element is <%s>, not <svg>", attrName(t.Name))
+			}
+			elements = append(elements, t.Name.Local)
+			root := len(elements) == 1
+			if skip > 0 || unsafeSVGElements[strings.ToLower(t.Name.Local)] {
+				skip++
+				continue
+			}
+			flush(false)
+			start := xml.StartElement{Name: t.Name}
+			for _, a := range t.Attr {
+				name := attrName(a.Name)
+				value := strings.TrimSpace(a.Value)
+				switch {
+				case strings.HasPrefix(strings.ToLower(name), "on"):
+					continue // event handler
+				case strings.Contains(strings.ToLower(strings.Join(strings.Fields(value), "")), "javascript:"):
+					continue
+				case name == "id":
+					a.Value = prefix + a.Value
+				case root && (name == "aria-label" || name == "aria-labelledby" || name == "role"):
+					continue // replaced by the description of the tag below
+				case name == "href" || name == "xlink:href":
+					if strings.HasPrefix(value, "#") && ids[value[1:]] {
+						a.Value = "#" + prefix + value[1:]
+					}
+				case svgIDLists[name]:
+					fields := strings.Fields(value)
+					for i, id := range fields {
+						if ids[id] {
+							fields[i] = prefix + id
+						}
+					}
+					a.Value = strings.Join(fields, " ")
+				default:
+					a.Value = rename(a.Value, false)
+				}
+				start.Attr = append(start.Attr, a)
+			}
+			if root {
+				start.Attr = append(start.Attr,
+					xml.Attr{Name: xml.Name{Local: "role"}, Value: "img"},
+					xml.Attr{Name: xml.Name{Local: "aria-label"}, Value: label})
+			}
+			pending = &start
+		case xml.EndElement:
+			if len(elements) > 0 {
+				elements = elements[:len(elements)-1]
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if pending != nil {
+				flush(true)
+				continue
+			}
+			out.WriteString("</" + attrName(t.Name) + ">")
+		case xml.CharData:
+			if skip > 0 || len(elements) == 0 {
+				continue
+			}
+			flush(false)
+			style := elements[len(elements)-1] == "style"
+			out.WriteString(textEscaper.Replace(rename(string(t), style)))
+		}
+		// Comments, processing instructions, and directives are dropped.
+	}
+	if out.Len() == 0 {
+		return "", fmt.Errorf("no <svg> element found")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
@@ -0,0 +1,28 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestInlineSVGUnsafe asserts that unsafe elements are removed regardless
+// of the case of their names.
+func TestInlineSVGUnsafe(t *testing.T) {
+	for _, src := range []string{
+		`<svg><script>alert(1)</script></svg>`,
+		`<svg><SCRIPT>alert(1)</SCRIPT></svg>`,
+		`<svg><Script>alert(1)</Script></svg>`,
+		`<svg><foreignObject><p>alert(1)</p></foreignObject></svg>`,
+		`<svg><foreignobject><p>alert(1)</p></foreignobject></svg>`,
+		`<svg><FOREIGNOBJECT><p>alert(1)</p></FOREIGNOBJECT></svg>`,
+	} {
+		out, err := inlineSVG([]byte(src), "p-", "label")
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		if lower := strings.ToLower(out); strings.Contains(lower, "script") || strings.Contains(lower, "foreignobject") || strings.Contains(out, "alert") {
+			t.Errorf("%s: unsafe element in %s", src, out)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package convert
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The packages that a post imports say a lot about its topics. A post that
+// imports `net/http` is likely about the Web, and one that imports `sync`
+// is likely about concurrency. A mapping from import paths to tags turns
+// the imports into front matter tags. A key of the mapping also matches
+// the packages below it, so "crypto" matches "crypto/sha256". The longest
+// matching key wins, and an empty tag turns off a shorter match.
+
+// DefaultImportTags returns the built-in mapping from import paths to tags.
+func DefaultImportTags() map[string]string {
+	return map[string]string{
+		"context":       "concurrency",
+		"crypto":        "cryptography",
+		"database/sql":  "database",
+		"encoding/json": "json",
+		"go/ast":        "parsing",
+		"go/parser":     "parsing",
+		"html/template": "web",
+		"image":         "images",
+		"net":           "networking",
+		"net/http":      "web",
+		"os/exec":       "processes",
+		"reflect":       "reflection",
+		"sync":          "concurrency",
+		"syscall/js":    "webassembly",
+		"testing":       "testing",
+		"unsafe":        "unsafe",
+	}
+}
+
+// importTag returns the tag for the import path, or "" if there is none.
+func importTag(path string, mapping map[string]string) string {
+	for {
+		if tag, ok := mapping[path]; ok {
+			return tag
+		}
+		i := strings.LastIndex(path, "/")
+		if i < 0 {
+			return ""
+		}
+		path = path[:i]
+	}
+}
+
+// TagsFromImports parses the imports of the Go source src and returns the
+// tags that mapping has for them, sorted and without duplicates.
+func TagsFromImports(src []byte, mapping map[string]string) ([]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse imports: %w", err)
+	}
+	seen := map[string]bool{}
+	var tags []string
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if tag := importTag(path, mapping); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// AddTags merges tags into the `tags` field of the front matter of d, and
+// into the `keywords` field if there is one. The tags of the author stay
+// in front, and tags that differ only in case count as the same tag.
+func AddTags(d *Document, tags []string) error {
+	if len(d.Sections) == 0 || d.Sections[0].Kind != FrontMatter {
+		return ErrMissingFrontMatter
+	}
+	fm := d.Sections[0]
+	data, err := decodeFrontMatter(fm)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"tags", "keywords"} {
+		old, found := frontMatterList(data, key)
+		if !found && key != "tags" {
+			continue
+		}
+		merged := mergeTags(old, tags)
+		if len(merged) == len(old) {
+			continue // leave the field as the author wrote it
+		}
+		quoted := make([]string, len(merged))
+		for i, tag := range merged {
+			quoted[i] = strconv.Quote(tag)
+		}
+		setFrontMatterField(fm, key, "["+strings.Join(quoted, ", ")+"]")
+	}
+	return nil
+}
+
+// frontMatterList returns the list of strings in the field key of the front
+// matter data. A single string counts as a list of one.
+func frontMatterList(data map[string]interface{}, key string) (list []string, found bool) {
+	for k, v := range data {
+		if !strings.EqualFold(k, key) {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			return []string{v}, true
+		case []interface{}:
+			for _, item := range v {
+				list = append(list, fmt.Sprint(item))
+			}
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// mergeTags appends the tags that are not in old yet to old.
+func mergeTags(old, tags []string) []string {
+	merged := append([]string(nil), old...)
+	for _, tag := range tags {
+		dup := false
+		for _, m := range merged {
+			dup = dup || strings.EqualFold(m, tag)
+		}
+		if !dup {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
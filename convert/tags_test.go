@@ -0,0 +1,71 @@
+package convert
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTagsFromImports(t *testing.T) {
+	src := []byte("/*\n+++\n+++\n*/\npackage main\n\nimport (\n\t\"crypto/sha256\"\n\t\"fmt\"\n\t\"net/http\"\n\t_ \"net/http/pprof\"\n\t\"net/url\"\n)\n")
+	mapping := DefaultImportTags()
+	mapping["net/url"] = ""
+	tags, err := TagsFromImports(src, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cryptography", "web"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got %v, want %v", tags, want)
+	}
+}
+
+func TestAddTags(t *testing.T) {
+	tests := []struct {
+		name, frontMatter string
+		tags, keywords    []string // nil if the field must not exist
+	}{
+		{"toml", "+++\ntitle = \"T\"\ntags = [\n  \"Go\",\n  \"Web\",\n]\n+++", []string{"Go", "Web", "json"}, nil},
+		{"yaml", "---\ntags:\n- Go\nkeywords: [go]\n---", []string{"Go", "web", "json"}, []string{"go", "web", "json"}},
+		{"json", "{\n  \"title\": \"T\"\n}", []string{"web", "json"}, nil},
+	}
+	for _, test := range tests {
+		d := Parse([]byte("/*\n" + test.frontMatter + "\n\n<!--more-->\n*/\n"))
+		if err := AddTags(d, []string{"web", "json"}); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		data, err := decodeFrontMatter(d.Sections[0])
+		if err != nil {
+			t.Errorf("%s: front matter is invalid: %v\n%s", test.name, err, verbatim(d.Sections[0].Text))
+			continue
+		}
+		for key, want := range map[string][]string{"tags": test.tags, "keywords": test.keywords} {
+			got, _ := frontMatterList(data, key)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s = %v, want %v\n%s", test.name, key, got, want, verbatim(d.Sections[0].Text))
+			}
+		}
+	}
+}
+
+// TestTagImportsWarning asserts that ConvertFile reports imports that
+// cannot be parsed, and converts the file without tags.
+func TestTagImportsWarning(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "post.go"):            "/*\n+++\ntitle = \"T\"\n+++\n*/\npackage main\n\nimport \"fmt\n",
+		filepath.Join(dir, "out", "placeholder"): "",
+	})
+	var warnings []error
+	c := &Converter{OutDir: filepath.Join(dir, "out"), TagImports: true, Warn: func(err error) { warnings = append(warnings, err) }}
+	if err := c.ConvertFile(filepath.Join(dir, "post.go")); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "cannot parse imports") {
+		t.Errorf("got warnings %v, want an import error", warnings)
+	}
+	if !isFile(filepath.Join(dir, "out", "post", "index.md")) {
+		t.Error("no page written")
+	}
+}
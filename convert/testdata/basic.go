@@ -0,0 +1,35 @@
+//go:generate this-is-ignored
+/*
+Everything before the front matter is ignored.
+
++++
+title = "Basic"
++++
+
+The summary.
+
+<!--more-->
+
+The intro.
+*/
+
+// ## Imports
+package main
+
+import "fmt"
+
+// A second pair directly after code.
+func hello() {
+	fmt.Println("hello")
+}
+
+/*
+### A doc section
+
+Rendered as a single column.
+*/
+
+// Code at the end of the file.
+func main() {
+	hello()
+}
@@ -0,0 +1,14 @@
+/*
+---
+title: "Doc after intro"
+---
+Summary
+<!--more-->
+Intro
+*/
+
+/*
+A doc section right after the intro.
+*/
+
+package main
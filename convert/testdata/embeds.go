@@ -0,0 +1,18 @@
+/*
++++
+title = "Embeds"
++++
+Summary
+<!--more-->
+VIDEO[A video](demo.mp4)
+
+ASCIINEMA[A recording](https://asciinema.org/a/12345)
+
+IFRAME[The playground](https://go.dev/play/)
+
+SVG[A diagram](diagram.svg)
+
+UNKNOWN[Not an embed tag](file.html)
+
+`VIDEO[Preformatted](demo.mp4)`
+*/
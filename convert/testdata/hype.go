@@ -0,0 +1,12 @@
+/*
++++
+title = "Hype"
++++
+Summary
+<!--more-->
+HYPE[An animation](anim.html)
+
+HYPE[An export without markers](newer.html)
+
+HYPE[The same animation again](anim.html)
+*/
@@ -0,0 +1,17 @@
+/*
++++
+title = "Images"
++++
+A summary image is not extended: ![Summary](summary.png)
+<!--more-->
+![Intro image](intro.png)
+
+	Preformatted: ![Not extended](pre.png)
+*/
+
+// ![Comment image](comment.png "Title")
+var a = 1
+
+/*
+![Doc image]( doc.png )
+*/
@@ -0,0 +1,18 @@
+/*
+{
+  "title": "JSON front matter",
+  "params": {"braces": "} and {"},
+  "tags": ["json"]
+}
+
+The summary.
+
+<!--more-->
+
+The intro.
+*/
+
+// ## Imports
+package main
+
+import "fmt"
@@ -0,0 +1,12 @@
+/*
++++
+title = "Links"
++++
+Summary
+<!--more-->
+See [the other post](../other/other.go) and [its intro](other/other.go#intro).
+
+[A missing post](../missing/missing.go) and [a plain Go file](../other/main.go).
+
+![An image is not a link](../other/other.go)
+*/
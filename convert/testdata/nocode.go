@@ -0,0 +1,15 @@
+/*
++++
+title = "Comments without code"
++++
+Summary
+<!--more-->
+Intro
+*/
+
+// A comment followed by a doc section.
+/*
+Doc
+*/
+
+// A comment at the end of the file.
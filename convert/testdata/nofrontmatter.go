@@ -0,0 +1,2 @@
+// There is no front matter here.
+package main
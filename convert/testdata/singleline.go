@@ -0,0 +1,13 @@
+/*
++++
+title = "Single-line doc"
++++
+Summary
+<!--more-->
+Intro */
+
+// Code.
+var a = 1
+/* A doc section on a single line. */
+// More code.
+var b = 2
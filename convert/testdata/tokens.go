@@ -0,0 +1,20 @@
+/*
++++
+title = "Tokens"
++++
+Summary
+<!--more-->
+Intro
+*/
+
+// Comment markers inside strings are code.
+var url = "http://example.com/path" // a trailing comment
+var raw = `
+// not a comment
+/* not a comment either */
+`
+
+// Block comments that start after code belong to the code.
+var x = f(a /* inline */, b) /* starts here
+and ends here */
+var y = 2
@@ -0,0 +1,69 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Before rendering, ConvertFile can verify that the images and Hype
+// animations of a post exist, either in the media folder next to the
+// source file or in the media directory at the output side. A missing file
+// is a problem at the line of the reference. Files in the source media
+// folder that the post does not refer to are reported as well, if desired.
+
+// isFile returns true if path exists.
+func isFile(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// verifyMedia reports references in d to images that exist in neither srcDir
+// nor dstDir. In strict mode, it returns the first missing file as *Error.
+// Missing files of embed tags are left to their Embedder.
+// With unused set, it also warns about files in srcDir that d does not refer to.
+func (c *Converter) verifyMedia(d *Document, srcDir, dstDir string, unused bool) error {
+	used := map[string]bool{}
+	for _, ref := range c.mediaRefs(d) {
+		path := filepath.Clean(filepath.FromSlash(ref.Path))
+		used[path] = true
+		for _, extra := range ref.Extra {
+			used[filepath.Clean(filepath.FromSlash(extra))] = true
+		}
+		if ref.Embed != "" {
+			continue
+		}
+		if isFile(filepath.Join(srcDir, path)) || isFile(filepath.Join(dstDir, path)) {
+			continue
+		}
+		if _, err := c.problem(ref.Line, &MissingMediaError{Path: ref.Path}); err != nil {
+			return err
+		}
+	}
+	if !unused {
+		return nil
+	}
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == srcDir {
+				return nil // no media folder, nothing unused
+			}
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if used[rel] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil // .DS_Store and the like
+		}
+		c.warn(&UnusedMediaError{Path: path})
+		return nil
+	})
+}
@@ -0,0 +1,43 @@
+package convert
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyMedia(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(src, "post", "here.png"):                        "x",
+		filepath.Join(src, "post", "unused.png"):                      "x",
+		filepath.Join(src, "post", "anim.hyperesources", "script.js"): "x",
+		filepath.Join(out, "post", "there.png"):                       "x",
+	})
+	doc := Parse([]byte("/*\n+++\n+++\n<!--more-->\n![a](here.png) ![b](there.png)\n\n" +
+		"![c](missing.png)\n\nHYPE[d](anim.html)\n*/\n"))
+
+	var warnings []error
+	c := &Converter{OutDir: out, Warn: func(err error) { warnings = append(warnings, err) }}
+	if err := c.verifyMedia(doc, filepath.Join(src, "post"), c.mediaPath("post"), true); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("want 2 warnings, got %v", warnings)
+	}
+	var e *Error
+	var missing *MissingMediaError
+	if !errors.As(warnings[0], &e) || e.Line != 7 || !errors.As(e, &missing) || missing.Path != "missing.png" {
+		t.Errorf("want missing.png at line 7, got %v", warnings[0])
+	}
+	var unused *UnusedMediaError
+	if !errors.As(warnings[1], &unused) || filepath.Base(unused.Path) != "unused.png" {
+		t.Errorf("want unused.png, got %v", warnings[1])
+	}
+
+	c.Strict = true
+	if err := c.verifyMedia(doc, filepath.Join(src, "post"), c.mediaPath("post"), false); !errors.As(err, &missing) {
+		t.Errorf("want *MissingMediaError in strict mode, got %v", err)
+	}
+}
@@ -3,7 +3,10 @@ module github.com/christophberger/gotohugo
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/fsnotify/fsnotify v1.7.0
 	github.com/google/gops v0.3.28
-	github.com/pkg/errors v0.9.1
+	github.com/yuin/goldmark v1.7.8
+	golang.org/x/net v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
 )
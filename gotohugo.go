@@ -21,7 +21,17 @@ If a .go2 file is present that matches the required naming scheme, `gotohugo` pr
 
 <!--more-->
 
-Extra #1: A non-standard "HYPE" tag can be used for inserting Tumult Hype HTML animations. This tag resembles an image tag but with the "!" replaced by "HYPE", like: `HYPE[Description](path/to/exported_hype.html)`. It is replaced by the corresponding HTML snippet that loads the animation. To create the animation files, export your Tumult Hype animation to HTML5 and ensure the "Also save HTML file" checkbox is checked. `gotohugo` then extracts the required HTML snippet from the file and copies the `hyperesources` directory to the output folder.
+Extra #1: A non-standard "HYPE" tag can be used for inserting Tumult Hype HTML animations. This tag resembles an image tag but with the "!" replaced by "HYPE", like: `HYPE[Description](path/to/exported_hype.html)`. It is replaced by the corresponding HTML snippet that loads the animation. To create the animation files, export your Tumult Hype animation to HTML5 and ensure the "Also save HTML file" checkbox is checked. `gotohugo` then extracts the required HTML snippet from the file and copies the `hyperesources` directory to the output folder. The snippet consists of the animation's container div and its loader script, with all resource URLs pointing to the post's media directory; the "copy these lines" comments of older Hype versions are not needed. A post can contain several animations. Each animation needs a unique name, though, because the container id is derived from it, and the loader script finds its container by that id. gotohugo reports an animation whose container id is already in use, and embeds only its `<noscript>` fallback.
+
+The HYPE tag is one of several embed tags of the same form, `NAME[Description](path)`. An embed tag replaces the whole line, so put it on a line of its own. The HTML of the tag goes between two comments, `<!--gotohugo:embed ... -->` with the original line and `<!--gotohugo:end-->`, so that `reverse` can restore the tag. The built-in embed tags are:
+
+* `HYPE[Description](animation.html)`: a Tumult Hype animation, as described above.
+* `VIDEO[Description](video.mp4)`: a `<video>` element for a video file in the media folder, or for the URL of a video file.
+* `ASCIINEMA[Description](12345)`: an [asciinema](https://asciinema.org) recording, by its ID or URL.
+* `SVG[Description](diagram.svg)`: an SVG image. The markup of the SVG file is inlined into the page, so that CSS can style the image and search engines can find its text. Scripts, event handlers, and `javascript:` links are removed, and element ids get the post name and the file name as a prefix, like `mypost-diagram-box`, to avoid collisions with other ids on the page. The description becomes the `aria-label` of the image, and it replaces the `aria-labelledby` of the SVG root element.
+* `IFRAME[Description](https://example.com)`: an `<iframe>` with any Web page.
+
+Library users can add their own embed tags through `Converter.Embeds`.
 
 Extra #2: gotohugo inserts Hugo shortcodes around doc and code parts to help creating a side-by-side layout à la docgo, where the code comments appear in an extra column left to the code. This very much adds to readability IMHO. This feature also comes with full Responsive Layout capability - if the viewport is too narrow, code and comment collapse into a single column.
 
@@ -30,35 +40,139 @@ Extra #3: `gotohugo` inserts the custom Hugo shortcode `{{< announcement >}}` af
 
 ## Usage
 
-	gotohugo [-out "path/to/outputDir"] <gofile.go>
-	gotohugo [-hugo "path/to/hugoRootDir"] <gofile.go>
-	gotohugo [-watch "dir/to/watch"] [-out "path/to/outputDir"] [-v]
-	gotohugo [-watch "dir/to/watch"] [-hugo "path/to/hugoRootDir"] [-v]
+	gotohugo convert [flags] <gofile.go>...
+	gotohugo watch [flags] [dir]
+	gotohugo all [flags] [dir]
+	gotohugo check <gofile.go>...
+	gotohugo reverse [flags] <name/index.md> > <name/name.go>
+	gotohugo clean [flags] <gofile.go>...
+	gotohugo new [-frontmatter toml|yaml] <dir/name>
+	gotohugo help [command]
 
-### Flags
+### Commands
 
-*`-out`: Specifies the output directory. Defaults to `./out`. The path must already exist. By convention it is the path to Hugo's `content/post/` directory.
-*`-hugo`: Specifies the Hugo root dir. Mutual exclusive to `-out`. When using `-hugo`, the output directory must point to the Hugo root directory. The markdown file will then be written to `<hugoRootDir>/content/post/<gofile.md>`. Hype files must already exist at `<hugoRootDir>/static/media/<gofile>/<hypefile>.html`, or else gotohugo fails replacing the HYPE tag with the corresponding Hype HTML.
-*`-watch`: Watches the given directory. (Default: Current dir.) This must be the parent directory of one or more project directories. Gotohugo will only watch for changes to files whose names are the same as their directory, e.g., `gotohugo/gotohugo.go`. This is because each Hugo post is made from exactly one .go file, and this .go file must be named after its directory, to
+*`convert`: Converts the given Go files. If the first argument is not a command, like in `gotohugo mypost/mypost.go`, gotohugo runs `convert`.
+*`watch`: Watches the given directory. (Default: Current dir.) This must be the parent directory of one or more project directories. Gotohugo will only watch for changes to files whose names are the same as their directory, e.g., `gotohugo/gotohugo.go`. This is because each Hugo post is made from exactly one .go file, and this .go file must be named after its directory, to
 distinguish it from other .go files that might also reside in the same dir but are not part of the blog post.
+*`all`: Converts all files `<name>/<name>.go` in the subdirectories of the given directory. (Default: Current dir.)
+*`check`: Checks the given files for violations of the rules below, instead of converting them. Each violation is printed as `file:line:col: message`, and the exit code is non-zero if there are any violations. Use this in a pre-commit hook.
+*`reverse`: Converts Markdown files generated by gotohugo back into Go source, and writes the source to stdout. Use this when someone has edited `<name>/index.md` directly, to bring the edits back into the `.go` file before the next conversion overwrites them. The Markdown file must have been generated with the default layout. Anything before the front matter, like a `//go:` directive, is not restored, and embed tags are restored from the comments around their HTML. (Hype snippets in Markdown files from gotohugo versions without these comments become HYPE tags with the animation name as their description.) Use the same `-out` or `-hugo` settings as for the conversion, to restore the original image paths.
+*`clean`: Removes what the conversion of the given Go files has written: the page, the notebook, the copies of the media files that the post refers to, and the downscaled variants of its images at the `-srcset` widths. Files that gotohugo did not write stay where they are, and the media subfolder next to the Go file is never touched, even if it lies within the output directory. Use the same flags as for the conversion.
+*`new`: Creates a new post: the directory `dir/name`, the media subfolder `dir/name/name`, and a skeleton `dir/name/name.go` that follows the rules below. The skeleton has front matter in TOML syntax, or in YAML syntax with `-frontmatter yaml`, with today's date and `draft = true`, a summary, the summary divider, an intro, and a comment/code pair. Its `//go:generate` directive converts the post on `go generate`. `go generate` runs in the post directory, so set the output directory in a `gotohugo.toml` above the post, or through `$HUGODIR`. gotohugo refuses to write a page into the media folder of its post. `new` does not overwrite an existing Go file.
+*`help`: Prints the list of commands, or the help text and the flags of the given command.
+
+### Flags
+
+`convert`, `watch`, `all`, `reverse`, and `clean` take the following flags. All commands take `-d`.
+
+*`-out`: Specifies the output directory. Defaults to the current directory. The path must already exist. By convention it is the path to Hugo's `content/post/` directory.
+*`-hugo`: Specifies the Hugo root dir. Mutual exclusive to `-out`. When using `-hugo`, the output directory must point to the Hugo root directory. The markdown file will then be written to `<hugoRootDir>/content/post/<gofile.md>`. Hype files must already exist at `<hugoRootDir>/static/media/<gofile>/<hypefile>.html`, or else gotohugo fails replacing the HYPE tag with the corresponding Hype HTML. gotohugo reads the site config (`hugo.toml`, `config.toml`, or their YAML or JSON variants, in the root dir or in `config/_default/`) and uses its `contentDir` and `staticDir` settings in place of `content` and `static`. To put the posts into a section other than `post`, set `section` below `params.gotohugo` in the site config, or use `-section`.
+*`-section`: With `-hugo`, the content section that receives the posts, like `tutorials`. Overrides the section from the site config.
+*`-format`: The output format. `hugo` (the default) generates Markdown with the Hugo shortcodes described above. `commonmark` generates plain Markdown without any shortcodes, for GitHub READMEs or other static site generators: prose becomes paragraphs, code goes into fenced `go` blocks, and there is no announcement and no Klipse class. `html` generates a self-contained `index.html` page with the side-by-side layout, embedded CSS, and syntax-highlighted code, for previewing a post without running Hugo.
+*`-keepimagepaths`: Do not extend the paths of image tags. Useful with `-format commonmark` when the images are located relative to the Markdown file.
+*`-notebook`: Also write a [Jupyter](https://jupyter.org) notebook `<gofile>.ipynb` into the page bundle, for the [gophernotes](https://github.com/gopherdata/gophernotes) kernel. Summary, intro, and doc sections become markdown cells, and each comment/code pair becomes a markdown cell followed by a code cell.
+*`-layout`: A directory containing layout templates (see below) that replace the built-in layout.
+*`-copymedia`: Copy the images, Hype HTML files, and `*.hyperesources` directories that a post refers to, from the media subfolder next to the Go file (see below) to the post's media directory at the output side. Files that have the same size and modification time at both places are not copied again. Enabled by default; use `-copymedia=false` to turn it off. gotohugo does not copy anything if the media directory at the output side lies within the directory of the Go file, as happens without an output directory, so that the source tree stays clean.
+*`-verifymedia`: Report each image tag whose file exists neither in the media subfolder next to the Go file nor in the post's media directory at the output side, with the line of the image tag. Enabled by default. With `-strict`, a missing image aborts the conversion.
+*`-unusedmedia`: With `-verifymedia`, also report the files in the media subfolder next to the Go file that the post does not refer to.
+*`-responsive`: Turn image tags into `<img>` elements with `width` and `height` attributes, to avoid layout shifts while the page loads. gotohugo reads the dimensions from the image file in the post's media directory at the output side. PNG, JPEG, and GIF images are supported; other image tags remain unchanged. `reverse` does not turn `<img>` elements back into image tags.
+*`-srcset`: With `-responsive`, a comma-separated list of image widths, like `320,640,1024`. For each width that is smaller than the image, gotohugo writes a downscaled copy `<image>-<width>w.<ext>` next to the image and lists the copies in the `srcset` attribute of the `<img>` element. Copies that are newer than the image are not written again.
+*`-enrich`: Add fields to the front matter that are tedious to maintain by hand: `lastmod`, the time of the last change of the Go file, `wordCount`, the number of words in the prose and the comments, `readingTime`, the reading time in minutes as Hugo computes it, and `codeLines`, the number of non-blank lines of code. If the Go file is under Git version control and has no uncommitted changes, `lastmod` is the time of the last commit that changed the file; otherwise, it is the modification time of the file. Fields that exist already get the new value, and new fields are added at the top of the front matter. Comments and all other fields stay as they are. The Go file itself is not changed.
+*`-tagimports`: Add tags for notable packages that the Go file imports to the `tags` field of the front matter, like `web` for `net/http` or `concurrency` for `sync`. A package also gets the tag of the package path above it, so `crypto/sha256` gets the tag of `crypto`. The tags of the author stay in front, and gotohugo only adds the tags that are not there yet, ignoring case. If the front matter has a `keywords` field, the tags are added there, too. To add or change tags, use the `importtags` table in `gotohugo.toml` (see below).
+*`-strict`: Abort the conversion of a file if gotohugo runs into a problem, like a missing Hype file or missing front matter, and leave the output file untouched. Without `-strict`, gotohugo logs the problem and, for problems in the prose, embeds a warning into the rendered page. Front matter problems are only logged, as a warning would break the front matter. In watch mode, a failed conversion is logged and watching continues.
 *`-d`: Debug-level logging.
 
+### Using gotohugo as a library
+
+The conversion is available as package `github.com/christophberger/gotohugo/convert`:
+
+	c := &convert.Converter{OutDir: "path/to/hugo", PostDir: "content/post", MediaDir: "static/media", PublicMediaDir: "media"}
+	err := c.ConvertFile("mypost/mypost.go")
+
+To add an embed tag, implement the `convert.Embedder` interface, or wrap a function into `convert.EmbedFunc`, and add it to the built-in embed tags:
+
+	c.Embeds = convert.DefaultEmbeds()
+	c.Embeds["GIST"] = convert.EmbedFunc(func(tag *convert.EmbedTag) (string, error) {
+		return `<script src="https://gist.github.com/` + tag.Path + `.js"></script>`, nil
+	})
+
+To take the directories from the config of a Hugo site, use `convert.ReadHugoSite`:
+
+	site, err := convert.ReadHugoSite("path/to/hugo")
+	site.Configure(c)
+
+`convert.Enrich` adds the fields of `-enrich` to the front matter of a `Document`, and `convert.DocumentStats` returns the numbers alone.
+
+`convert.TagsFromImports` returns the tags for the imports of Go source, and `convert.AddTags` merges tags into the front matter of a `Document`.
+
+`Converter.Clean` removes the output of a Go file again, and `convert.NewPost` creates the skeleton of a new post.
+
+`Converter.Convert` converts from an `io.Reader` to an `io.Writer` instead. Set `Converter.Name` to the post's base name for resolving media paths.
+
+Conversion happens in two steps. `convert.Parse` splits the source into a `Document` of typed sections (front matter, summary, intro, doc sections, and comment/code pairs, each with its source line range), and `Converter.Render` turns a `Document` into Markdown. A `Document` can be inspected or modified between these two steps.
+
+### Layout templates
+
+The markup around each section comes from [text/template](https://pkg.go.dev/text/template) templates. The built-in layout produces the `div` shortcodes described above. To use different shortcodes, put one or more `*.tmpl` files into a directory and pass it via `-layout`. The files replace templates of the built-in layout of the `-format`, so the templates that a directory does not define come from that layout. Each file replaces the template with the file's base name, or it can contain one or more `[[define "name"]]...[[end]]` blocks. Templates use `[[` and `]]` as delimiters, so that Hugo shortcodes can be written as they are.
+
+* `page`: Everything after the front matter. Fields: `.FrontMatter`, `.Content`.
+* `summary`, `intro`, `doc`: The summary, the intro (including the summary divider and the announcement), and doc sections. Field: `.Text`.
+* `source`: A run of comment/code pairs. Field: `.Content`.
+* `pair`: A comment/code pair. Fields: `.Text` (the comment), `.Code`.
+* `plain`: Lines outside any section. Field: `.Text`.
+
+All templates also receive `.Name`, the base name of the post, and `.Section`, the parsed section.
+
+Example `pair.tmpl`:
+
+	{{< comment >}}
+	[[.Text]]{{< /comment >}}
+	[[if .Code]]{{< highlight go >}}
+	[[.Code]]{{< /highlight >}}
+	[[end]]
+
+### Configuration file
+
+Instead of passing the same flags on every call, put them into a file `gotohugo.toml`. gotohugo looks for this file in the directory of the Go file and in all directories above, so a `gotohugo.toml` at the root of a project applies to all posts of the project. A post directory can have a `gotohugo.toml` of its own that overrides some of the project settings for this post. The keys are the names of the flags:
+
+	hugo = "../blog"       # or out = "...". Paths are relative to the directory of gotohugo.toml.
+	section = "tutorials"
+	layout = "layout"
+	format = "hugo"
+	responsive = true
+	srcset = [320, 640]
+	strict = true
+
+Some settings are only available in `gotohugo.toml`:
+
+* `announcement`: The name of the shortcode after the summary divider. Default: `announcement`.
+* `codeclass`: The class of the code div. Default: `language-klipse-go`, for the [Klipse](https://github.com/viebel/klipse) plugin.
+* `embeds`: The embed tags to process, like `["HYPE", "SVG"]`. Default: all built-in embed tags.
+* `importtags`: Tags for `-tagimports`, in addition to the built-in ones. An empty tag turns off a built-in tag:
+
+		[importtags]
+		"github.com/gorilla/mux" = "web"
+		"net" = ""
+
+Library users can read the files through `convert.LoadConfig` and create a Converter through `Config.Converter`.
+
 ### Precedence rules for flags and environment variables
 
-* If either `-hugo` is used, or if `$HUGODIR` is set, `-out` has no effect.
-* If neither of the flags nor `$HUGODIR` are set, output defaults to `./out/`.
+* Flags override the settings in `gotohugo.toml`, and the settings in `gotohugo.toml` override `$HUGODIR`.
+* `-hugo` takes precedence over `-out`.
+* If neither the flags, nor `gotohugo.toml`, nor `$HUGODIR` set an output directory, gotohugo writes to the current directory.
 
 
 ## Notes
 
-1. Unlike gotomarkdown, gotohugo does not handle any media files itself. All media files must be available at the output destination, in a subdirectory whose name is the base name of the go file.
+1. All media files must be available at the output destination, in a subdirectory whose name is the base name of the go file. With `-copymedia` (the default), gotohugo copies the files that the post refers to from the media subfolder next to the Go file to that destination.
    Example: mytutorial.go gets turned into content/post/mytutorial.md, and all media files then must reside in static/media/mytutorial/.
    The point here is that right now, Hugo does not create subdirectories for posts; they all are created in `<hugo>/content/post`. To reduce clutter, all media files related to a post should therefore be put into a subdirectory of the post's base name. Further, to avoid that Hugo grabs Hype HTML files and adds them to the list of posts, this subdirectory must reside outside the /post/ directory.
    As far as Hugo is concerned, this is just a convention; however, gotohugo relies on this file structure.
 
 2. To play nice with the Permalink feature of Hugo, gotohugo automatically creates the full path to the image file, starting from the content directory. That is, if your image resides in `static/media/mypost/myimage.jpg`, and your Markdown tag is like, `[My Image](myimage.jpg)`, gotohugo expands the tag to `[My Image](/media/mypost/myimage.jpg`.
 
-3. Because of 1., gotohugo tries to find any Hype animation hmtl file in `static/media/mypost/hypename.html`. Gotohugo needs this file to extract the HTML snippet that replaces the HYPE tag. If gotohugo does not find the animation HTML that the HYPE tag points to, it substitutes a warning message that will be visible on the rendered page.
+3. Because of 1., gotohugo tries to find any Hype animation hmtl file in `static/media/mypost/hypename.html`. Gotohugo needs this file to extract the HTML snippet that replaces the HYPE tag. If gotohugo does not find the animation HTML that the HYPE tag points to, it substitutes a warning message that will be visible on the rendered page, or with `-strict`, it aborts the conversion.
 
 
 ## How to write proper gotohugo-friendly code documents
@@ -90,7 +204,7 @@ A line comment **must** be followed by code. Otherwise, use a multiline comment
 After an optional //go:... directive and the beginning of the first multiline comment delimiter, add the necessary Hugo front matter.
 
 Front matter **must** exist. Hugo cannot process a post properly without front matter. `gotohugo` fails processing the source file if it contains no front matter.
-Use the toml or yaml syntax, depending on the setting in the Hugo configuration.
+Use the TOML, YAML, or JSON syntax, like Hugo does: TOML between `+++` lines, YAML between `---` lines, or a JSON object that starts with a `{` line and ends with the matching `}`. gotohugo parses the front matter and reports a syntax error with its line, so that it shows up before Hugo builds the site. With `-strict`, a syntax error aborts the conversion.
 
 **Note:** Anything before the front matter is **not** turned into Markdown. Put things like License remarks and other internal notes there.
 
@@ -115,7 +229,7 @@ For example, if the markdown file is named `gotohugo.md`, then the images and an
 
 ### Images and Hype animations MUST exist at the output dir, in the aforementioned subfolder.
 
-Reason is that `gotohugo` fetches an HTML snippet from the Hype HTML. If it cannot find the Hype HTML, it errors out.
+Reason is that `gotohugo` fetches an HTML snippet from the Hype HTML. If it cannot find the Hype HTML, it errors out. With `-copymedia`, `gotohugo` copies the files there before converting.
 
 
 ### Do not specify the path of an image or animation html.
@@ -127,11 +241,27 @@ Example:
 `![image](image.png)` gets expanded to `![image](/post/gotohugo/image.png)`
 
 
+### Link to other posts through their Go files.
+
+A link to the Go file of another post, like `[see part 2](../part2/part2.go)`, works when browsing the source tree. `gotohugo` rewrites the link target to Hugo's `ref` shortcode for the post `part2`, so that the link also works on the site. An anchor like `../part2/part2.go#setup` is kept. With `-format commonmark` or `-format html`, the link points to the generated file of the other post instead, like `../part2/index.md`. If the other post does not exist, the link remains unchanged and gotohugo reports it like a missing Hype file.
+
+
 ### Example of a gotohugo-friendly source code file.
 
 Examine `gotohugo.go`, which follows all the above rules and conventions.
 
 
+## Tests
+
+The `convert` package has golden-file tests: each `convert/testdata/<name>.go` file is converted and compared to `convert/testdata/<name>.md`. After an intended change of the output, regenerate the golden files with
+
+	go test ./convert -update
+
+and review the diff. `FuzzConvert` checks that the output has balanced div shortcodes and closed code fences for arbitrary input:
+
+	go test ./convert -fuzz FuzzConvert
+
+
 ## TODO
 
 [] Replace strings with []byte where this can help avoiding excessive copying & garbage creating.
@@ -145,468 +275,168 @@ This code is governed by a BSD 3-clause license that can be found in LICENSE.txt
 */
 
 // ## Imports and Globals
+//
+// The conversion itself lives in the `convert` package, so that it can be
+// used as a library. This file is just the command line wrapper around it.
 
 package main
 
 import (
 	"flag"
 	"fmt"
-	"io/fs"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 
-	"github.com/pkg/errors"
-
 	"github.com/google/gops/agent"
-)
 
-const (
-	preformatPtrn    = `\x60|^ {4,}|^\t\s*` // \x60 = backtick
-	commentPtrn      = `^\s*//\s?`
-	commentStartPtrn = `^\s*/\*\s?`
-	commentEndPtrn   = `\s*\*/\s*$`
-	frontmatterPtrn  = `^\s*(\+\+\+)|(---)\s*$`
-	imagePtrn        = `(!\[[^\]]+\]\( *)([^"\)]*?)(.*?\))`
-	hypePtrn         = `HYPE\[[^\]]+\]\( *([^\)]+) *\)`
-	srcPtrn          = `(src=")(.*\.hyperesources/)`
+	"github.com/christophberger/gotohugo/convert"
 )
 
+// The settings of a conversion. The commands that convert files register
+// them as flags through `addConvertFlags`.
 var (
-	preformat        = regexp.MustCompile(preformatPtrn)    // matches preformatted text
-	commentRe        = regexp.MustCompile(commentPtrn)      // matches single-line comments
-	commentStart     = regexp.MustCompile(commentStartPtrn) // matches /* comment delimiter
-	commentEnd       = regexp.MustCompile(commentEndPtrn)   // matches */ comment delimiter
-	frontmatterDelim = regexp.MustCompile(frontmatterPtrn)  // matches Hugo front matter delimiters
-	imageTag         = regexp.MustCompile(imagePtrn)        // matches Markdown image tag
-	hypeTag          = regexp.MustCompile(hypePtrn)         // matches Hype animation tag
-	srcTag           = regexp.MustCompile(srcPtrn)          // matches Hype container div src tag
-	debug            = flag.Bool("d", false, "Enable debug-level logging.")
-	watch            = flag.String("watch", "", "Watch dirs recursively. If <name>/<name>.go changes, convert the file to Hugo Markdown.")
-	outDir           = flag.String("out", "", "Output directory. Defaults to './out/'. Overrides $HUGODIR. If -hugo is set, -out has no effect.")
-	hugoDir          = flag.String("hugo", "", "Hugo root directory. Overrides -out and $HUGODIR.")
-	recursive        = flag.String("recursive", "", "Convert recursively all abc/abc.go files")
-	postDir          = "" // gets set to "/content/post" if -hugo is used instead of -out
-	mediaDir         = "" // gets set to "/static/media" if -hugo is used instead of -out
-	publicMediaDir   = "" // the media dir as the Web server sees it. Gets set to "/media" if -hugo is used.
+	debug      bool
+	outDir     string
+	hugoDir    string
+	section    string
+	layout     string
+	format     string
+	keepPaths  bool
+	notebook   bool
+	copyMedia  bool
+	verify     bool
+	unused     bool
+	responsive bool
+	srcset     string
+	strict     bool
+	enrich     bool
+	tagImports bool
+
+	frontMatter string // the front matter syntax of a new post
 )
 
-// ## First, some helper functions
+// ## First, a helper function
 //
 // debug prints to the log output if the debug flag is set.
 func dbg(args ...interface{}) {
-	if *debug {
+	if debug {
 		log.Println(args...)
 	}
 }
 
-// isLineComment returns true if the text in the input string starts with //.
-func isLineComment(line string) bool {
-	return commentRe.FindString(line) != ""
-}
-
-// isCommentStart detects the start of a multiline comment.
-func isCommentStart(line string) bool {
-	return commentStart.FindString(line) != ""
-}
-
-// isCommentEnd detects the end of a multiline comment.
-func isCommentEnd(line string) bool {
-	return commentEnd.FindString(line) != ""
-}
-
-// isFrontmatterDelim receives an integer and increases it by one
-// if it finds a frontmatter deliminter in the current line.
-func isFrontmatterDelim(line string) bool {
-	return frontmatterDelim.FindString(line) != ""
-}
-
-// isSummaryDivider detects the summary divider.
-func isSummaryDivider(line string) bool {
-	return strings.Contains(line, "<!--more-->")
-}
-
-func isPreformatted(line string) bool {
-	return preformat.FindString(line) != ""
-}
-
-// extendPath takes a string that should contain a filename
-// and prepends `/<basename>/media/` to it.
-func extendPath(filename, basename string) string {
-	return string(os.PathSeparator) + filepath.Join(basename, publicMediaDir, filename)
-}
-
-// func extendSrc takes a string that should contain the line from the HTML snippet that
-// starts with `<div id="animation_hype_container"...` and prepends `/<basename>/media/` to
-// the src="..." string.
-func extendSrc(src, basename string) string {
-	return string(srcTag.ReplaceAllString(src, "$1"+extendPath("$2", basename)))
-}
-
-// extendImagePath receives a line of text and searches for an image
-// tag. If it finds one, it extends the image path to include
-// `/<basename>/media/` and returns the modified line.
-// Otherwise it returns the unmodified line.
-func extendImagePath(line, basename string) string {
-	if isPreformatted(line) {
-		return line
+// parseWidths turns a comma-separated list of image widths into a slice.
+func parseWidths(list string) (widths []int, err error) {
+	if list == "" {
+		return nil, nil
 	}
-	return string(imageTag.ReplaceAllString(line, "$1"+extendPath("$2", basename)+"$3"))
-}
-
-/*
-imageTag should properly match the following image tags:
-
-`![Animation GIF](animation.gif)`
-
-![Animation GIF]( animation.gif )
-
-(Same but with spaces around the path:) ![Animation GIF with spaces]( animation.gif )
-
-`![Animation GIF with title](animation.gif "Title")` (With image title)
-
-![Animation GIF with title](animation.gif "Title")
-
-    ![Image with space in path](an image.png) (With a space in the path)
-
-![Image with space in path](an image.png)
-
-	Same but with title: ![With space and title](an image.png "Title")
-
-![With space and title](an image.png "Title")
-*/
-
-// getHTMLSnippet opens the file determined by `path`, and scans the file for the HTML
-// snippet to insert. It returns the HTML snippet.
-func getHTMLSnippet(path, basename string) (out string) {
-	hypeHTML, err := os.ReadFile(path)
-	if err != nil {
-		wrappedErr := fmt.Errorf("no Hype file found at  %s . Please run gotohugo again after creating the Hype animation HTML export.: %w", path, err)
-		log.Println(wrappedErr.Error()) // notify the developer via shell
-		return wrappedErr.Error()       // remind the developer by adding the message to the rendered page
-	}
-	inSnippet := false
-	// Remove carriage returns.
-	lines := strings.ReplaceAll(string(hypeHTML), "\r", "")
-	// Split at newline and process each line.
-	for _, line := range strings.Split(lines, "\n") {
-		if strings.Contains(line, "<!-- copy these lines to your document: -->") {
-			inSnippet = true
-			continue
-		}
-		if strings.Contains(line, "<!-- end copy -->") {
-			if inSnippet {
-				break
-			}
-			inSnippet = false // there can be more than one "end copy" strings in the file
-		}
-		if inSnippet {
-			out += extendSrc(strings.Trim(line, "\t"), basename) + "\n"
+	for _, field := range strings.Split(list, ",") {
+		w, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid image width %q in -srcset", field)
 		}
+		widths = append(widths, w)
 	}
-	return out + "\n"
+	return widths, nil
 }
 
-// replaceHypeTag identifies a tag like `HYPE[description](animation.html)`
-// and replaces it by the corresponding HTML snippet generated by [Tumult Hype](http://tumult.com)
-// through the "Export as HTML5 > Also save .html file" option.
+// ## Converting files
 //
-// It returns:
-// * out: the (possibly modified) line
-// * found: true if a HYPE tag was found (and processed)
-func replaceHypeTag(line, base string) (out string, found bool, err error) {
-	// Do not process preformatted text
-	if isPreformatted(line) {
-		return line, false, nil
-	}
-	// Find the HYPE tag if it exists.
-	matches := hypeTag.FindStringSubmatch(line)
-	if len(matches) == 0 {
-		return line, false, nil
-	}
-	if len(matches) < 2 {
-		return "", false, errors.New("found Hype tag but no valid path, in line:\n" + line)
-	}
-	// substitute the Hype HTML snippet for the HYPE tag.
-	path := matches[1]
-	out = getHTMLSnippet(filepath.Join(*outDir, mediaDir, base, path), base)
-	out += "<noscript class=\"nohype\"><em>Please enable JavaScript to view the animation.</em></noscript>\n"
-	return out, true, err
-}
-
-// div returns a Hugo shortcode of the form
-// &#123;{< div <name> >}}.
-func div(name string) string {
-	return "{{< div " + name + " >}}\n"
+// addConvertFlags registers the settings of a conversion as flags of `fs`.
+func addConvertFlags(fs *flag.FlagSet) {
+	fs.StringVar(&outDir, "out", "", "Output directory. Defaults to the current directory. Overrides $HUGODIR. If -hugo is set, -out has no effect.")
+	fs.StringVar(&hugoDir, "hugo", "", "Hugo root directory. Overrides -out and $HUGODIR.")
+	fs.StringVar(&section, "section", "", "With -hugo, the content section for the posts. Overrides params.gotohugo.section in the site config. Default: 'post'.")
+	fs.StringVar(&layout, "layout", "", "Directory with layout templates (*.tmpl) that replace the default shortcodes.")
+	fs.StringVar(&format, "format", "hugo", "Output format: 'hugo' (Markdown with Hugo shortcodes), 'commonmark' (plain Markdown), or 'html' (standalone HTML page).")
+	fs.BoolVar(&keepPaths, "keepimagepaths", false, "Do not extend the paths of image tags.")
+	fs.BoolVar(&notebook, "notebook", false, "Also write a Jupyter notebook <name>.ipynb for the gophernotes kernel.")
+	fs.BoolVar(&copyMedia, "copymedia", true, "Copy the media files that a post refers to from <name>/ next to the source file to the post's media directory.")
+	fs.BoolVar(&verify, "verifymedia", true, "Report images that the post refers to but that do not exist.")
+	fs.BoolVar(&unused, "unusedmedia", false, "With -verifymedia, also report files in the media folder that the post does not refer to.")
+	fs.BoolVar(&responsive, "responsive", false, "Turn image tags into <img> elements with the width and height of the image.")
+	fs.StringVar(&srcset, "srcset", "", "With -responsive, a comma-separated list of widths for downscaled copies of each image, like '320,640'.")
+	fs.BoolVar(&enrich, "enrich", false, "Add lastmod, wordCount, readingTime, and codeLines to the front matter.")
+	fs.BoolVar(&tagImports, "tagimports", false, "Add tags for notable imported packages, like 'web' for net/http, to the front matter.")
+	fs.BoolVar(&strict, "strict", false, "Abort a conversion on problems like a missing Hype file, instead of embedding a warning into the page.")
 }
 
-// divEnd returns the end marker of a div.
-func divEnd(name string) string {
-	return "{{< divend >}} <!--" + name + "-->\n"
-}
-
-// convert receives a string containing commented Go code and converts it
-// line by line into a Markdown document.
-func convert(in, base string) (out string) {
-	const (
-		beforefrontmatter = iota
-		frontmatter
-		summary
-		intro
-		doc
-		comment
-		code
-		none
-	)
-	status := beforefrontmatter
-
-	// Turn CR/LF line endings into pure LF line endings.
-	in = strings.Replace(in, "\r", "", -1)
-	// Split at newline and process each line.
-	for _, line := range strings.Split(in, "\n") {
-
-		// First we do some line processing that does **not** necessarily call
-		// `continue`.
-
-		// Images and Hype animations can be located in the intro,
-		// in comments, or in pure doc sections.
-		if status == doc || status == comment || status == intro {
-
-			// If the line contains an image tag, extend the path of the tag.
-			line = extendImagePath(line, base)
-
-			// If the line contains a Hype tag, replace it with the Hype HTML snippet.
-			line, found, err := replaceHypeTag(line, base)
-			if err != nil {
-				e := fmt.Errorf("failed generating Hype tag from line  %s: %w", line, err)
-				fmt.Printf("%s\n", e)
-				out += e.Error()
-			}
-			if found {
-				out += line
-				continue
-			}
-		}
-
-		// if the line belongs to Hugo front matter, append it to out
-		// and continue with the next line.
-		if status == beforefrontmatter {
-			if isFrontmatterDelim(line) { // start of front matter.
-				status = frontmatter
-				out += line + "\n"
-				continue
-			}
-			// Discard anything before the front matter. There should **only**
-			// be an optional //go:... directive, and the start of the first
-			// multiline comment, and nothing else.
-			continue
-		}
-
-		// Within front matter, if the second delimiter is found,
-		// switch to summary section.
-		// Also generate a `gotohugo` namespace div.
-		if status == frontmatter {
-			out += line + "\n"
-			if isFrontmatterDelim(line) { // end of front matter. Summary section begins.
-				status = summary
-				out += div("gotohugo")
-				out += div("summary doc")
-				continue
-			}
-		}
-
-		// After the summary divider, -
-		// - insert the announcement shortcode
-		// - insert author
-		// - start the intro.
-		if status == summary {
-			if isSummaryDivider(line) {
-				out += divEnd("summary doc")
-				out += "\n" + line + "\n\n"
-				out += "{{< announcement >}}\n"
-				// out += "{{< author >}}\n"
-				out += div("intro doc")
-				status = intro
-				continue
-			}
-			out += line + "\n"
-			continue
-		}
-
-		// Intro is finished when the comment end delimiter occurs.
-		// The status afterwards is not defined. Comment/code pairs might follow,
-		// or another multiline comment. Or the end of the file.
-		if status == intro {
-			if isCommentEnd(line) {
-				out += divEnd("intro doc")
-				status = none
-				continue
-			}
-			out += line + "\n"
-			continue
-		}
-
-		// A line comment can occur after code, after another line comment,
-		// or when no other section is active.
-		if status == none || status == code {
-			if isLineComment(line) {
-				// If the last line was code, add a closing code fence.
-				if status == code {
-					out += "```\n\n"
-					out += divEnd("code")
-					out += divEnd("ccpair")
-					out += div("ccpair")
-				}
-				// Multiline comments switch the status to none at the end.
-				// In this case, start a new source section.
-				if status == none {
-					out += div("source")
-					out += div("ccpair")
-				}
-				status = comment
-				out += div("comment")
-				// Strip the comment delimiters.
-				out += commentRe.ReplaceAllString(line, "") + "\n"
-				continue
-			}
-		}
-
-		// While processing line comments.
-		if status == comment {
-			// If still looking at a line comment, strip the delims.
-			// Else switch into code status.
-			if isLineComment(line) {
-				out += commentRe.ReplaceAllString(line, "") + "\n"
-				continue
-			} else {
-				status = code
-				out += divEnd("comment")
-				// class language-klipse-go is used by the Klipse plugin.
-				out += div("code language-klipse-go")
-				out += "\n```go\n"
-				out += line + "\n"
-				continue
-			}
-		}
-
-		// While processing code, look out for comments.
-		if status == code {
-
-			// A line comment occurs. End the code section.
-			if isLineComment(line) {
-				status = comment
-				out += "```\n\n"
-				out += divEnd("code")
-				out += divEnd("ccpair")
-				out += div("ccpair")
-				out += div("comment")
-				out += commentRe.ReplaceAllString(line, "") + "\n"
-				continue
-			}
-
-			// A multiline comment starts. End the code section and switch to
-			// single-column layout by closing the "source" div.
-			if isCommentStart(line) {
-				status = doc
-				out += "```\n\n"
-				out += divEnd("code")
-				out += divEnd("ccpair")
-				out += divEnd("source")
-				out += div("doc")
-				out += commentStart.ReplaceAllString(line, "") + "\n"
-				continue
-			}
-			out += line + "\n"
-			continue
-
-		}
-
-		// At the end of a multiline comment, we don't know for sure
-		// what comes next, so we set the status to none.
-		if status == doc {
-			if isCommentEnd(line) {
-				out += divEnd("doc")
-				status = none
-				continue
+// newConverter creates a Converter for the files in directory `dir`. The
+// settings come from the flags that are set in `fs`, the `gotohugo.toml` files
+// that apply to `dir`, and `$HUGODIR`, in this order of precedence.
+func newConverter(fs *flag.FlagSet, dir string) (*convert.Converter, error) {
+	cfg, err := convert.LoadConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "out":
+			cfg.Out = outDir
+			if hugoDir == "" {
+				cfg.Hugo = "" // -hugo takes precedence over -out
 			}
-			out += line + "\n"
-			continue
-		}
-
-		// Outside any status? Just pass the line to the output.
-		if status == none {
-			out += line + "\n"
+		case "hugo":
+			cfg.Hugo = hugoDir
+		case "section":
+			cfg.Section = section
+		case "layout":
+			cfg.Layout = layout
+		case "format":
+			cfg.Format = format
+		case "keepimagepaths":
+			cfg.KeepImagePaths = keepPaths
+		case "notebook":
+			cfg.Notebook = notebook
+		case "copymedia":
+			cfg.CopyMedia = copyMedia
+		case "verifymedia":
+			cfg.VerifyMedia = verify
+		case "unusedmedia":
+			cfg.UnusedMedia = unused
+		case "responsive":
+			cfg.Responsive = responsive
+		case "srcset":
+			cfg.Srcset, flagErr = parseWidths(srcset)
+		case "enrich":
+			cfg.Enrich = enrich
+		case "tagimports":
+			cfg.TagImports = tagImports
+		case "strict":
+			cfg.Strict = strict
 		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
 	}
-
-	// The last line in the file might be code.
-	// We need a closing code fence then, and we need to close the divs, too.
-	if status == code {
-		out += "\n```\n"
-		out += divEnd("code")
-		out += divEnd("ccpair")
+	if cfg.Hugo == "" && cfg.Out == "" {
+		cfg.Hugo = os.Getenv("HUGODIR")
 	}
-
-	// Close the `gotohugo` namespace div.
-	out += divEnd("gotohugo")
-
-	return out
+	dbg("Configuration files for", dir+":", cfg.Files)
+	return cfg.Converter()
 }
 
-// ## Converting a file
-//
-// ### Again, some helper functions
-//
-// `base` strips the extension from a filename. For some reason, this
-// function is missing from the standard path library.
-func base(name string) string {
-	return strings.TrimSuffix(name, filepath.Ext(name))
-}
-
-// ### Now the actual conversion
-//
-// `convertFile` takes a file name, reads that file, converts it to
-// Markdown, and writes it to `*outDir/[post/]<basename>/index.md`.
-// It creates the page bundle directory but expects the base path to exist.
-func convertFile(filename string) (err error) {
-	src, err := ioutil.ReadFile(filename)
+// convertFile converts the file `filename` with the settings for its directory.
+func convertFile(fs *flag.FlagSet, filename string) error {
+	c, err := newConverter(fs, filepath.Dir(filename))
 	if err != nil {
-		log.Fatal("Cannot read file " + filename + "\n" + err.Error())
+		return err
 	}
-	name := filepath.Base(filename)
-	basename := base(name) // strip ".go"
-	// Create the output directory if it doesn't exist.
-	outpath := filepath.Join(*outDir, postDir, basename)
-	if _, err := os.Stat(outpath); err != nil {
-		if os.IsNotExist(err) {
-			if err = os.Mkdir(outpath, fs.ModeDir|0774); err != nil {
-				return fmt.Errorf("Cannot create output directory  %s: %w", outpath, err)
-			}
-		} else {
-			return fmt.Errorf("Cannot stat output directory  %s: %w", outpath, err)
-		}
-	}
-	outname := filepath.Join(outpath, "index.md")
-	md := convert(string(src), basename)
-	err = os.WriteFile(outname, []byte(md), 0644) // -rw-r--r--
-	if err != nil {
-		return fmt.Errorf("cannot write file  %s: %w", outname, err)
-	}
-	return nil
+	return c.ConvertFile(filename)
 }
 
 // newConvertFunc creates a function that converts the file described by `path`.
 // The function is used to create a `time.AfterFunc` function (which takes no parameters).
-func newConvertFunc(path string) func() {
+func newConvertFunc(fs *flag.FlagSet, path string) func() {
 	return func() {
 		log.Println("Start converting   ", path+"...")
-		err := convertFile(path)
+		err := convertFile(fs, path)
 		if err != nil {
 			log.Println(err)
 		}
@@ -617,7 +447,7 @@ func newConvertFunc(path string) func() {
 // `watchAndConvert` observes the file system under directory <dir>.
 // If a file named `<name>.go` in directory `<name>` has changed,
 // convert it to Hugo Markdown.
-func watchAndConvert(dirname string) error {
+func watchAndConvert(fs *flag.FlagSet, dirname string) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return fmt.Errorf("cannot create new Watcher: %w", err)
@@ -646,8 +476,8 @@ func watchAndConvert(dirname string) error {
 		if dirEntry.IsDir() {
 			// If the entry is a directory, watch for creation of or changes to a
 			// Go file under that dir of the same name as the dir, e.g. `watch/watch.go`.
-
-			// ignore dot folders
+			//
+			// Ignore dot folders.
 			if fname[0] == '.' {
 				continue
 			}
@@ -696,7 +526,7 @@ func watchAndConvert(dirname string) error {
 						}
 					}
 					// give the file system a second to consolidate the write, then convert the file
-					time.AfterFunc(time.Second, newConvertFunc(event.Name))
+					time.AfterFunc(time.Second, newConvertFunc(fs, event.Name))
 				}
 			}
 		case err := <-watcher.Errors:
@@ -711,20 +541,20 @@ func watchAndConvert(dirname string) error {
 // Input: directory to start. This directory should contain
 // blog directories containing go files that follow the pattern
 // `abc/abc.go`.
-func convertAll(dir string) error {
+func convertAll(fs *flag.FlagSet, dir string) error {
 	allEntries, err := os.ReadDir(dir)
 	if err != nil {
 		return fmt.Errorf("cannot read directory  %s: %w", dir, err)
 	}
 	for _, entry := range allEntries {
 		if entry.IsDir() {
-			file := filepath.Join(entry.Name(), entry.Name()+".go")
+			file := filepath.Join(dir, entry.Name(), entry.Name()+".go")
 			if _, err := os.Stat(file); os.IsNotExist(err) {
 				dbg("Skipping non-existent file", file)
 				continue
 			}
 			log.Println("Converting", file)
-			err := convertFile(file)
+			err := convertFile(fs, file)
 			if err != nil {
 				return fmt.Errorf("cannot convert  %s: %w", file, err)
 			}
@@ -733,54 +563,276 @@ func convertAll(dir string) error {
 	return nil
 }
 
-// ## main - Where it all starts
-func main() {
-
-	// Start the Gops agent.
-	if err := agent.Listen(agent.Options{}); err != nil {
-		log.Fatal(err)
+// checkFile prints compiler-style diagnostics for all rule violations in the
+// given file. It returns false if there are any.
+func checkFile(filename string) (bool, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return false, fmt.Errorf("cannot read file %s: %w", filename, err)
 	}
+	diags := convert.Check(src)
+	for _, d := range diags {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", filename, d)
+	}
+	return len(diags) == 0, nil
+}
 
-	flag.Parse()
-	hugoDirEnv := os.Getenv("HUGODIR")
-
-	// If $HUGODIR is set and -hugo isn't, copy $HUGODIR into *hugoDir.
-	if len(*hugoDir) == 0 && len(hugoDirEnv) > 0 {
-		*hugoDir = hugoDirEnv
+// reverseFile converts the Markdown file `<name>/index.md` back to Go source
+// and writes the result to stdout.
+func reverseFile(c *convert.Converter, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", filename, err)
 	}
+	defer f.Close()
+	rc := *c
+	rc.Name = filepath.Base(filepath.Dir(filename))
+	return rc.Reverse(f, os.Stdout)
+}
 
-	// If *hugoDir is set and *outDir isn't, use *hugoDir. Also set the subdirs accordingly.
-	if len(*hugoDir) > 0 && len(*outDir) == 0 {
-		*outDir = *hugoDir
-		postDir = filepath.Join("content", "post")
-		mediaDir = "media"       // media dir as Hugo sees it
-		publicMediaDir = "media" // media dir as the Web server sees it
+// cleanFile removes the output of the Go file `filename`.
+func cleanFile(fs *flag.FlagSet, filename string) error {
+	c, err := newConverter(fs, filepath.Dir(filename))
+	if err != nil {
+		return err
+	}
+	removed, err := c.Clean(filename)
+	for _, path := range removed {
+		log.Println("Removed", path)
 	}
+	return err
+}
 
-	// With `-watch=<dir>`, watch the subdirs of `<dir>` for changes.
-	if len(*watch) > 0 {
-		log.Println("Running in watch mode. Hit Ctrl-C to stop.")
-		err := watchAndConvert(*watch)
-		if err != nil {
-			log.Println(fmt.Errorf("conversion error: %w", err))
-		}
-	} else {
-		for _, filename := range flag.Args() {
-			log.Println("Converting", filename)
-			err := convertFile(filename)
+// ## Commands
+//
+// Each command has a flag set of its own. `-d` is available for all commands.
+type command struct {
+	name  string
+	args  string                 // the arguments, for the usage line
+	short string                 // a one-line description for the list of commands
+	long  string                 // the help text
+	flags func(fs *flag.FlagSet) // registers the flags of the command
+	nargs int                    // the minimum number of arguments
+	run   func(fs *flag.FlagSet) error
+}
+
+var commands = []*command{
+	{
+		name:  "convert",
+		args:  "<name/name.go>...",
+		short: "convert Go files to Markdown",
+		long:  "Convert the given Go files to Hugo Markdown, or to the format set by -format.",
+		flags: addConvertFlags,
+		nargs: 1,
+		run: func(fs *flag.FlagSet) error {
+			for _, filename := range fs.Args() {
+				log.Println("Converting", filename)
+				if err := convertFile(fs, filename); err != nil {
+					return fmt.Errorf("conversion error: %w", err)
+				}
+			}
+			log.Println("Done.")
+			return nil
+		},
+	},
+	{
+		name:  "watch",
+		args:  "[dir]",
+		short: "convert Go files whenever they change",
+		long: "Watch the subdirectories of dir (default: the current directory), and convert\n" +
+			"a file <name>/<name>.go whenever it changes. Hit Ctrl-C to stop.",
+		flags: addConvertFlags,
+		run: func(fs *flag.FlagSet) error {
+			log.Println("Running in watch mode. Hit Ctrl-C to stop.")
+			return watchAndConvert(fs, argOr(fs, "."))
+		},
+	},
+	{
+		name:  "all",
+		args:  "[dir]",
+		short: "convert all posts in a directory",
+		long:  "Convert all files <name>/<name>.go in the subdirectories of dir (default: the current directory).",
+		flags: addConvertFlags,
+		run: func(fs *flag.FlagSet) error {
+			dir := argOr(fs, ".")
+			log.Println("Converting all articles in", dir)
+			if err := convertAll(fs, dir); err != nil {
+				return fmt.Errorf("recursive conversion error: %w", err)
+			}
+			log.Println("Done.")
+			return nil
+		},
+	},
+	{
+		name:  "check",
+		args:  "<name/name.go>...",
+		short: "check Go files for rule violations",
+		long: "Check the given files for violations of the gotohugo rules, instead of converting\n" +
+			"them. Each violation is printed as file:line:col: message, and the exit code is\n" +
+			"non-zero if there are any violations. Use this in a pre-commit hook.",
+		nargs: 1,
+		run: func(fs *flag.FlagSet) error {
+			ok := true
+			for _, filename := range fs.Args() {
+				fileOK, err := checkFile(filename)
+				if err != nil {
+					return err
+				}
+				ok = ok && fileOK
+			}
+			if !ok {
+				os.Exit(1)
+			}
+			return nil
+		},
+	},
+	{
+		name:  "reverse",
+		args:  "<name/index.md>...",
+		short: "convert generated Markdown back to Go",
+		long: "Convert Markdown files generated by gotohugo back into Go source, and write the\n" +
+			"source to stdout. Use the same -out or -hugo settings as for the conversion.\n" +
+			"Embed tags are restored from the comments around their HTML.",
+		flags: addConvertFlags,
+		nargs: 1,
+		run: func(fs *flag.FlagSet) error {
+			c, err := newConverter(fs, ".")
 			if err != nil {
-				log.Fatal(fmt.Errorf("conversion error: %w", err))
+				return err
+			}
+			for _, filename := range fs.Args() {
+				if err := reverseFile(c, filename); err != nil {
+					return fmt.Errorf("reverse conversion error: %w", err)
+				}
 			}
+			return nil
+		},
+	},
+	{
+		name:  "new",
+		args:  "<dir/name>",
+		short: "create a new post",
+		long: "Create the post directory dir/name, the media subfolder dir/name/name, and a\n" +
+			"skeleton dir/name/name.go with front matter, summary, and intro. The post is\n" +
+			"dated today and marked as a draft.",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&frontMatter, "frontmatter", "toml", "The syntax of the front matter: 'toml' or 'yaml'.")
+		},
+		nargs: 1,
+		run: func(fs *flag.FlagSet) error {
+			file, err := convert.NewPost(fs.Arg(0), frontMatter, time.Now())
+			if err != nil {
+				return err
+			}
+			log.Println("Created", file)
+			return nil
+		},
+	},
+	{
+		name:  "clean",
+		args:  "<name/name.go>...",
+		short: "remove the output of Go files",
+		long: "Remove the pages, notebooks, copied media files, and image variants that the\n" +
+			"conversion of the given Go files has written. Use the same settings as for the\n" +
+			"conversion, including -srcset. Files that gotohugo did not write, and the media\n" +
+			"folders next to the Go files, are left alone.",
+		flags: addConvertFlags,
+		nargs: 1,
+		run: func(fs *flag.FlagSet) error {
+			for _, filename := range fs.Args() {
+				if err := cleanFile(fs, filename); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// argOr returns the first argument of `fs`, or `def` if there is none.
+func argOr(fs *flag.FlagSet, def string) string {
+	if fs.NArg() > 0 {
+		return fs.Arg(0)
+	}
+	return def
+}
+
+// findCommand returns the command `name`, or nil if there is none.
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
 		}
 	}
+	return nil
+}
 
-	if len(*recursive) > 0 {
-		log.Println("Converting all articles in", *recursive)
-		err := convertAll(*recursive)
-		if err != nil {
-			log.Fatalln(fmt.Errorf("recursive conversion error: %w", err))
+// flagSet creates the flag set of `cmd`, with a usage function
+// that prints the help text of the command.
+func (cmd *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.BoolVar(&debug, "d", false, "Enable debug-level logging.")
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gotohugo %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.long)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// usage prints the list of commands.
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: gotohugo <command> [flags] [arguments]\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s  %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'gotohugo help <command>' for the flags of a command.")
+}
+
+// ## main - Where it all starts
+//
+// The first argument is the command. If it is not a command,
+// like in `gotohugo mypost/mypost.go`, the command is `convert`.
+func main() {
+
+	// Start the Gops agent.
+	if err := agent.Listen(agent.Options{}); err != nil {
+		log.Fatal(err)
+	}
+
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if cmd := findCommand(args[1]); cmd != nil {
+				cmd.flagSet().Usage()
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n", args[1])
 		}
+		usage()
+		return
 	}
 
-	log.Println("Done.")
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		cmd = findCommand("convert")
+	} else {
+		args = args[1:]
+	}
+	fs := cmd.flagSet()
+	fs.Parse(args) // exits on error
+	if fs.NArg() < cmd.nargs {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(fs); err != nil {
+		log.Fatal(err)
+	}
 }